@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2017, 2022
+© Copyright IBM Corporation 2017, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -25,6 +25,7 @@ import (
 	"os/signal"
 	"strings"
 
+	"github.com/ibm-messaging/mq-container/internal/ready"
 	"github.com/ibm-messaging/mq-container/pkg/name"
 )
 
@@ -39,6 +40,13 @@ func queueManagerHealthy(ctx context.Context) (bool, error) {
 	// Run the command and wait for completion
 	out, err := cmd.CombinedOutput()
 	fmt.Printf("%s", out)
+	// Include the native HA status, if runmqserver has saved it
+	status, statusErr := ready.ReadNativeHAStatus()
+	if statusErr != nil {
+		fmt.Println(statusErr)
+	} else if status != nil {
+		fmt.Print(status.Summary())
+	}
 	if err != nil {
 		fmt.Println(err)
 		return false, err
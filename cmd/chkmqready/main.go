@@ -14,13 +14,12 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// chkmqready checks that MQ is ready for work, by checking if the MQ listener port is available
+// chkmqready checks that MQ is ready for work, by checking if the MQ listener ports are available
 package main
 
 import (
 	"context"
 	"fmt"
-	"net"
 	"os"
 	"os/signal"
 
@@ -48,18 +47,10 @@ func doMain() int {
 	if err != nil {
 		return 1
 	}
+	printNativeHAStatus()
 	switch status {
 	case ready.StatusActiveQM:
-		conn, err := net.Dial("tcp", "127.0.0.1:1414")
-		if err != nil {
-			fmt.Println(err)
-			return 1
-		}
-		err = conn.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
-		return 0
+		return checkListeners(ctx)
 	case ready.StatusStandbyQM:
 		fmt.Printf("Detected queue manager running in standby mode")
 		return 10
@@ -71,6 +62,36 @@ func doMain() int {
 	}
 }
 
+// checkListeners checks that all of the configured listeners are available, reporting any which are not
+func checkListeners(ctx context.Context) int {
+	listeners, err := ready.GetListeners()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	rc := 0
+	for _, listener := range listeners {
+		err = ready.CheckListener(ctx, listener)
+		if err != nil {
+			fmt.Printf("Listener %v is not available: %v\n", listener, err)
+			rc = 1
+		}
+	}
+	return rc
+}
+
+// printNativeHAStatus prints the native HA status saved by runmqserver, if there is one
+func printNativeHAStatus() {
+	status, err := ready.ReadNativeHAStatus()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if status != nil {
+		fmt.Print(status.Summary())
+	}
+}
+
 func main() {
 	os.Exit(doMain())
 }
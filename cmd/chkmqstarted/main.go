@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2021, 2022
+© Copyright IBM Corporation 2021, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -24,7 +24,9 @@ import (
 	"os/exec"
 	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/ibm-messaging/mq-container/internal/ready"
 	"github.com/ibm-messaging/mq-container/pkg/name"
 )
 
@@ -52,6 +54,14 @@ func doMain() int {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer cancel()
 
+	// Report the initialization phase of runmqserver, to help diagnose slow or failed starts
+	state, err := ready.ReadStartupState()
+	if err != nil {
+		fmt.Println(err)
+	} else if state != nil {
+		fmt.Print(state.Summary(time.Now()))
+	}
+
 	started, err := queueManagerStarted(ctx)
 	if err != nil {
 		return 2
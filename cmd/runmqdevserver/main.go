@@ -36,10 +36,10 @@ func getLogFormat() string {
 		logFormat = strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
 	}
 
-	if logFormat != "" && (logFormat == "basic" || logFormat == "json") {
+	if logFormat != "" && logger.IsValidFormat(logFormat) {
 		return logFormat
 	} else {
-		//this is the case where value is either empty string or set to something other than "basic"/"json"
+		//this is the case where value is either empty string or set to something other than a supported format
 		logFormat = "basic"
 	}
 
@@ -68,6 +68,11 @@ func configureLogger() error {
 		if err != nil {
 			return err
 		}
+	case logger.FormatLogfmt, logger.FormatECS, logger.FormatOTel:
+		log, err = logger.NewLoggerWithFormat(os.Stderr, d, f, n)
+		if err != nil {
+			return err
+		}
 	case "basic":
 		log, err = logger.NewLogger(os.Stderr, d, false, n)
 		if err != nil {
@@ -77,6 +82,14 @@ func configureLogger() error {
 		log, err = logger.NewLogger(os.Stdout, d, false, n)
 		return fmt.Errorf("invalid value for LOG_FORMAT: %v", f)
 	}
+	// This process is replaced by runmqserver, which also watches for changes to the log levels
+	levels := strings.TrimSpace(os.Getenv("MQ_LOGGING_LEVEL"))
+	if levels != "" {
+		err = log.SetLevels(levels)
+		if err != nil {
+			return fmt.Errorf("Invalid value for MQ_LOGGING_LEVEL: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,157 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/metrics"
+	"github.com/ibm-messaging/mq-container/internal/tls"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// certExpiryWarningDaysDefault are the numbers of days before a certificate expires when warnings are logged,
+// if MQ_TLS_EXPIRY_WARNING_DAYS is not set
+const certExpiryWarningDaysDefault = "30,7,1"
+
+// certExpiryInterval is how often the certificates are checked
+const certExpiryInterval = time.Hour
+
+// certExpiry checks the certificates for expiry, once it has been started
+var certExpiry *certExpiryMonitor
+
+// getCertExpiryWarningDays returns the numbers of days before a certificate expires when warnings are logged,
+// from largest to smallest
+func getCertExpiryWarningDays() ([]int, error) {
+	value := strings.TrimSpace(os.Getenv("MQ_TLS_EXPIRY_WARNING_DAYS"))
+	if value == "" {
+		value = certExpiryWarningDaysDefault
+	}
+	days := []int{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d, err := strconv.Atoi(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("Invalid value for MQ_TLS_EXPIRY_WARNING_DAYS: %v.  The value must be a comma-separated list of numbers of days", value)
+		}
+		days = append(days, d)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
+
+// certExpiryMonitor logs warnings as certificates approach their expiry, and provides the expiry of each
+// certificate to the metrics
+type certExpiryMonitor struct {
+	webKeystore string
+	password    string
+	warningDays []int
+	log         *logger.Logger
+	now         func() time.Time
+	list        func(webKeystore string, password string) ([]tls.CertificateInfo, error)
+
+	mutex sync.Mutex
+	// warned is the smallest number of days before expiry which has been logged for each certificate, or
+	// zero once the certificate has expired
+	warned map[string]int
+}
+
+func newCertExpiryMonitor(webKeystore string, password string, warningDays []int) *certExpiryMonitor {
+	return &certExpiryMonitor{
+		webKeystore: webKeystore,
+		password:    password,
+		warningDays: warningDays,
+		log:         log.With("component", "tls"),
+		now:         time.Now,
+		list:        tls.ListCertificates,
+		warned:      make(map[string]int),
+	}
+}
+
+// monitorCertificateExpiry checks the certificates now, and then at regular intervals until the context is cancelled
+func monitorCertificateExpiry(ctx context.Context, webKeystore string, password string, warningDays []int) {
+	certExpiry = newCertExpiryMonitor(webKeystore, password, warningDays)
+	certExpiry.check()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(certExpiryInterval):
+				certExpiry.check()
+			}
+		}
+	}()
+}
+
+// checkCertificateExpiry checks the certificates now, if monitoring has been started
+func checkCertificateExpiry() {
+	if certExpiry != nil {
+		certExpiry.check()
+	}
+}
+
+// check reads the certificates, provides them to the metrics, and logs a warning for each certificate which
+// has passed a warning threshold since it was last checked
+func (m *certExpiryMonitor) check() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	certificates, err := m.list(m.webKeystore, m.password)
+	if err != nil {
+		m.log.Debugf("Unable to read all certificates to check their expiry: %v", err)
+	}
+	metrics.SetCertificates(certificates)
+
+	now := m.now()
+	current := make(map[string]int)
+	for _, certificate := range certificates {
+		// The expiry date is part of the key, so that warnings start again when a certificate is renewed
+		key := fmt.Sprintf("%s/%s/%s/%d", certificate.Store, certificate.Label, certificate.Subject, certificate.NotAfter.Unix())
+		previous, warned := m.warned[key]
+		remaining := certificate.NotAfter.Sub(now)
+		if remaining <= 0 {
+			if !warned || previous > 0 {
+				m.log.With("store", certificate.Store, "label", certificate.Label).Errorf("Certificate '%s' with label '%s' in the %s keystore expired on %s", certificate.Subject, certificate.Label, certificate.Store, certificate.NotAfter.UTC().Format(time.RFC3339))
+			}
+			current[key] = 0
+			continue
+		}
+		threshold := 0
+		for _, days := range m.warningDays {
+			if remaining <= time.Duration(days)*24*time.Hour {
+				threshold = days
+			}
+		}
+		if threshold > 0 && (!warned || threshold < previous) {
+			m.log.With("store", certificate.Store, "label", certificate.Label).Warnf("Certificate '%s' with label '%s' in the %s keystore expires within %d days, on %s", certificate.Subject, certificate.Label, certificate.Store, threshold, certificate.NotAfter.UTC().Format(time.RFC3339))
+			current[key] = threshold
+		} else if warned {
+			current[key] = previous
+		}
+	}
+	// Forget certificates which have been removed
+	m.warned = current
+}
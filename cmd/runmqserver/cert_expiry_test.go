@@ -0,0 +1,111 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/tls"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+var certExpiryWarningDaysTests = []struct {
+	value    string
+	expected []int
+	valid    bool
+}{
+	{"", []int{30, 7, 1}, true},
+	{"1, 14,60", []int{60, 14, 1}, true},
+	{"7", []int{7}, true},
+	{"0", nil, false},
+	{"30,seven", nil, false},
+}
+
+func TestGetCertExpiryWarningDays(t *testing.T) {
+	for _, table := range certExpiryWarningDaysTests {
+		t.Run(table.value, func(t *testing.T) {
+			t.Setenv("MQ_TLS_EXPIRY_WARNING_DAYS", table.value)
+			days, err := getCertExpiryWarningDays()
+			if table.valid && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !table.valid && err == nil {
+				t.Fatalf("Expected an error; got %v", days)
+			}
+			if !reflect.DeepEqual(days, table.expected) {
+				t.Errorf("Expected %v; got %v", table.expected, days)
+			}
+		})
+	}
+}
+
+func TestCertExpiryWarnings(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	certificate := tls.CertificateInfo{
+		Store:    tls.StoreQueueManager,
+		Label:    "default",
+		Subject:  "CN=qm1",
+		NotAfter: now.Add(40 * 24 * time.Hour),
+	}
+	buf := new(bytes.Buffer)
+	l, err := logger.NewLogger(buf, false, false, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newCertExpiryMonitor("", "", []int{30, 7, 1})
+	m.log = l
+	m.now = func() time.Time { return now }
+	m.list = func(string, string) ([]tls.CertificateInfo, error) {
+		return []tls.CertificateInfo{certificate}, nil
+	}
+
+	var checks = []struct {
+		days     int
+		expected string
+	}{
+		{40, ""},
+		{29, "expires within 30 days"},
+		{28, ""},
+		{6, "expires within 7 days"},
+		{6, ""},
+		{-1, "expired on"},
+		{-2, ""},
+	}
+	for _, c := range checks {
+		buf.Reset()
+		now = certificate.NotAfter.Add(-time.Duration(c.days) * 24 * time.Hour)
+		m.check()
+		out := buf.String()
+		if c.expected == "" && out != "" {
+			t.Errorf("Expected no messages at %d days; got %q", c.days, out)
+		}
+		if c.expected != "" && !strings.Contains(out, c.expected) {
+			t.Errorf("Expected message containing %q at %d days; got %q", c.expected, c.days, out)
+		}
+	}
+
+	// A renewed certificate is warned about again
+	buf.Reset()
+	certificate.NotAfter = now.Add(24 * time.Hour)
+	m.check()
+	if !strings.Contains(buf.String(), "expires within 1 days") {
+		t.Errorf("Expected warning for renewed certificate; got %q", buf.String())
+	}
+}
@@ -0,0 +1,226 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fdcDir is the directory where FDC files are written
+const fdcDir = "/var/mqm/errors"
+
+// Values for MQ_LOGGING_CONSOLE_FDC
+const (
+	fdcDisabled = "false"
+	fdcEnabled  = "true"
+	fdcHeader   = "header"
+)
+
+// getFDCMode returns whether FDC files are reported, and whether the full header is included
+func getFDCMode() (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MQ_LOGGING_CONSOLE_FDC")))
+	switch mode {
+	case "":
+		return fdcEnabled, nil
+	case fdcDisabled, fdcEnabled, fdcHeader:
+		return mode, nil
+	}
+	return "", fmt.Errorf("Invalid value for MQ_LOGGING_CONSOLE_FDC: %v.  Allowed values are 'true', 'false' and 'header'", mode)
+}
+
+// fdcWatcher reports each FDC record written to the FDC files in a directory
+type fdcWatcher struct {
+	dir           string
+	includeHeader bool
+	mf            mirrorFunc
+	hostname      string
+	// offsets are the positions in each file up to which records have been reported
+	offsets map[string]int64
+}
+
+// mirrorFDCs reports FDC records written after this function is called, as log messages.  Each message
+// includes the probe ID, component, process, major error code and path of the FDC file.
+func mirrorFDCs(ctx context.Context, wg *sync.WaitGroup, dir string, includeHeader bool, mf mirrorFunc) {
+	// #nosec G104 - the host name is left empty if it is not available
+	hostname, _ := os.Hostname()
+	w := &fdcWatcher{
+		dir:           dir,
+		includeHeader: includeHeader,
+		mf:            mf,
+		hostname:      hostname,
+		offsets:       make(map[string]int64),
+	}
+	// Ignore the records in any existing files
+	w.scan(false)
+	wg.Add(1)
+	go func() {
+		watcher := newFileWatcher(ctx, dir)
+		defer func() {
+			watcher.close()
+			log.Debugf("Finished monitoring FDC files in %v", dir)
+			wg.Done()
+		}()
+		for {
+			w.scan(true)
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				watcher.wait()
+			}
+		}
+	}()
+}
+
+// scan checks the FDC files for new records, and reports them if required
+func (w *fdcWatcher) scan(report bool) {
+	files, err := filepath.Glob(filepath.Join(w.dir, "*.FDC"))
+	if err != nil {
+		return
+	}
+	current := make(map[string]bool)
+	for _, path := range files {
+		current[path] = true
+		offset, known := w.offsets[path]
+		if !known && !report {
+			fi, err := os.Stat(path)
+			if err == nil {
+				w.offsets[path] = fi.Size()
+			}
+			continue
+		}
+		headers, newOffset, err := readFDCHeaders(path, offset)
+		if err != nil {
+			log.Debugf("Unable to read FDC file %v: %v", path, err)
+			continue
+		}
+		w.offsets[path] = newOffset
+		for _, header := range headers {
+			w.report(path, header)
+		}
+	}
+	// Forget files which have been deleted, in case the name is used again
+	for path := range w.offsets {
+		if !current[path] {
+			delete(w.offsets, path)
+		}
+	}
+}
+
+// report mirrors a log message for an FDC record
+func (w *fdcWatcher) report(path string, header map[string]string) {
+	t := time.Now()
+	if utc, err := strconv.ParseFloat(header["UTC Time"], 64); err == nil {
+		t = time.Unix(0, int64(utc*float64(time.Second)))
+	}
+	entry := map[string]interface{}{
+		"ibm_datetime":       t.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		"loglevel":           "ERROR",
+		"message":            fmt.Sprintf("FDC file %v written by %v (%v): Probe Id %v, Component %v, Major Errorcode %v", path, header["Program Name"], header["Process"], header["Probe Id"], header["Component"], header["Major Errorcode"]),
+		"host":               w.hostname,
+		"ibm_serverName":     header["QueueManager"],
+		"ibm_processName":    header["Program Name"],
+		"ibm_processId":      header["Process"],
+		"ibm_probeId":        header["Probe Id"],
+		"ibm_component":      header["Component"],
+		"ibm_majorErrorcode": header["Major Errorcode"],
+		"ibm_fdcPath":        path,
+		"type":               "mq_fdc",
+	}
+	if w.includeHeader {
+		entry["ibm_fdcHeader"] = header
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.Errorf("Failed to report FDC file %v: %v", path, err)
+		return
+	}
+	w.mf(string(b), false)
+}
+
+// readFDCHeaders reads the headers of the FDC records in a file, starting at the specified offset.  The
+// offset returned is the end of the data which has been read, excluding any header which is incomplete.
+func readFDCHeaders(path string, offset int64) ([]map[string]string, int64, error) {
+	// #nosec G304 - no harm, we open readonly and check error.
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if fi.Size() < offset {
+		// The file has been replaced by a smaller file
+		offset = 0
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, offset, err
+	}
+	headers := []map[string]string{}
+	r := bufio.NewReader(f)
+	var header map[string]string
+	headerOffset := offset
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// A partial line is read again next time
+			break
+		}
+		lineOffset := offset
+		offset += int64(len(line))
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "+-") {
+			if header != nil && header["Probe Id"] != "" {
+				headers = append(headers, header)
+				header = nil
+			} else {
+				header = make(map[string]string)
+				headerOffset = lineOffset
+			}
+			continue
+		}
+		if header == nil {
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			// Not a header, so ignore the record
+			header = nil
+			continue
+		}
+		key, value, found := strings.Cut(strings.Trim(line, "| "), ":-")
+		if found {
+			header[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if header != nil {
+		// The header is still being written
+		offset = headerOffset
+	}
+	return headers, offset, nil
+}
@@ -0,0 +1,139 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testFDCHeader = `+-----------------------------------------------------------------------------+
+|                                                                             |
+| IBM MQ First Failure Symptom Report                                         |
+| ===================================                                         |
+|                                                                             |
+| Date/Time         :- Mon June 26 2023 10:00:00 UTC                          |
+| UTC Time          :- 1687773600.123456                                      |
+| Probe Id          :- XC130031                                               |
+| Component         :- xehAsySignalHandler                                    |
+| Program Name      :- amqzxma0                                               |
+| Process           :- 1234                                                   |
+| QueueManager      :- QM1                                                    |
+| Major Errorcode   :- xecE_W_UNEXPECTED_ASYNC_SIGNAL                         |
++-----------------------------------------------------------------------------+
+
+MQM Function Stack
+xehAsySignalHandler
+`
+
+func TestReadFDCHeaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "AMQ1234.0.FDC")
+	// Write a complete record, and the start of a second record
+	err := os.WriteFile(path, []byte(testFDCHeader+testFDCHeader[:400]), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, offset, err := readFDCHeaders(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 1 {
+		t.Fatalf("Expected 1 header; got %v", len(headers))
+	}
+	if headers[0]["Probe Id"] != "XC130031" || headers[0]["Major Errorcode"] != "xecE_W_UNEXPECTED_ASYNC_SIGNAL" {
+		t.Errorf("Unexpected header values: %v", headers[0])
+	}
+	if offset != int64(len(testFDCHeader)) {
+		t.Errorf("Expected offset to be the start of the incomplete header (%v); got %v", len(testFDCHeader), offset)
+	}
+	// Complete the second record
+	err = os.WriteFile(path, []byte(testFDCHeader+testFDCHeader), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, _, err = readFDCHeaders(path, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 1 {
+		t.Errorf("Expected 1 header for the second record; got %v", len(headers))
+	}
+}
+
+func TestMirrorFDCs(t *testing.T) {
+	dir := t.TempDir()
+	// An FDC file which exists before the watcher starts is not reported
+	err := os.WriteFile(filepath.Join(dir, "AMQ1.0.FDC"), []byte(testFDCHeader), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mutex sync.Mutex
+	messages := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	mirrorFDCs(ctx, &wg, dir, true, func(msg string, isQMLog bool) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		messages = append(messages, msg)
+		return true
+	})
+	path := filepath.Join(dir, "AMQ2.0.FDC")
+	err = os.WriteFile(path, []byte(testFDCHeader), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mutex.Lock()
+		count := len(messages)
+		mutex.Unlock()
+		if count > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 FDC message; got %v", messages)
+	}
+	var entry map[string]interface{}
+	err = json.Unmarshal([]byte(messages[0]), &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"ibm_probeId":        "XC130031",
+		"ibm_component":      "xehAsySignalHandler",
+		"ibm_processId":      "1234",
+		"ibm_majorErrorcode": "xecE_W_UNEXPECTED_ASYNC_SIGNAL",
+		"ibm_fdcPath":        path,
+		"ibm_datetime":       "2023-06-26T10:00:00.123Z",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Expected %v=%v; got %v", k, v, entry[k])
+		}
+	}
+	if _, ok := entry["ibm_fdcHeader"].(map[string]interface{}); !ok {
+		t.Errorf("Expected full header to be included; got %v", entry)
+	}
+}
@@ -25,8 +25,12 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ibm-messaging/mq-container/internal/command"
+	"github.com/ibm-messaging/mq-container/internal/health"
+	"github.com/ibm-messaging/mq-container/internal/logsink"
+	"github.com/ibm-messaging/mq-container/internal/ready"
 	"github.com/ibm-messaging/mq-container/pkg/logger"
 	"github.com/ibm-messaging/mq-container/pkg/mqini"
 )
@@ -36,12 +40,18 @@ var log *logger.Logger
 
 var collectDiagOnFail = false
 
+// logForwarder sends the container log and mirrored logs to the configured log sinks, if any
+var logForwarder *logsink.Forwarder
+
 func logTerminationf(format string, args ...interface{}) {
 	logTermination(fmt.Sprintf(format, args...))
 }
 
 func logTermination(args ...interface{}) {
 	msg := fmt.Sprint(args...)
+	health.SetLastError(msg)
+	// #nosec G104 - the termination message is also written to the log
+	ready.SetStartupFailed(msg)
 	// Write the message to the termination log.  This is not the default place
 	// that Kubernetes will look for termination information.
 	log.Debugf("Writing termination message: %v", msg)
@@ -50,7 +60,11 @@ func logTermination(args ...interface{}) {
 	if err != nil {
 		log.Debug(err)
 	}
-	log.Error(msg)
+	if startupPhase != "" {
+		log.With("phase", startupPhase).Error(msg)
+	} else {
+		log.Error(msg)
+	}
 
 	if collectDiagOnFail {
 		logDiagnostics()
@@ -64,10 +78,10 @@ func getLogFormat() string {
 		logFormat = strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
 	}
 
-	if logFormat != "" && (logFormat == "basic" || logFormat == "json") {
+	if logFormat != "" && logger.IsValidFormat(logFormat) {
 		return logFormat
 	} else {
-		//this is the case where value is either empty string or set to something other than "basic"/"json"
+		//this is the case where value is either empty string or set to something other than a supported format
 		logFormat = "basic"
 	}
 
@@ -89,6 +103,10 @@ func formatBasic(obj map[string]interface{}) string {
 		}
 	}
 	sort.Strings(inserts)
+	// Show which source a message came from, for sources other than the queue manager and web server
+	if tag, ok := obj["ibm_logSource"].(string); ok {
+		obj["message"] = fmt.Sprintf("[%v] %v", tag, obj["message"])
+	}
 	if len(inserts) > 0 {
 		return fmt.Sprintf("%s %s [%v]\n", obj["ibm_datetime"], obj["message"], strings.Join(inserts, ", "))
 	}
@@ -187,8 +205,12 @@ func formatBasic(obj map[string]interface{}) string {
 
 // mirrorSystemErrorLogs starts a goroutine to mirror the contents of the MQ system error logs
 func mirrorSystemErrorLogs(ctx context.Context, wg *sync.WaitGroup, mf mirrorFunc) (chan error, error) {
+	cp, err := getCheckpointer("qmgr", "system")
+	if err != nil {
+		return nil, err
+	}
 	// Always use the JSON log as the source
-	return mirrorLog(ctx, wg, "/var/mqm/errors/AMQERR01.json", false, mf, false)
+	return mirrorLogWithCheckpoint(ctx, wg, "/var/mqm/errors/AMQERR01.json", false, mf, false, cp, errorLogRotation("/var/mqm/errors")...)
 }
 
 // mirrorQueueManagerErrorLogs starts a goroutine to mirror the contents of the MQ queue manager error logs
@@ -199,18 +221,44 @@ func mirrorQueueManagerErrorLogs(ctx context.Context, wg *sync.WaitGroup, name s
 		log.Debug(err)
 		return nil, err
 	}
-	f := filepath.Join(mqini.GetErrorLogDirectory(qm), "AMQERR01.json")
-	return mirrorLog(ctx, wg, f, fromStart, mf, true)
+	cp, err := getCheckpointer("qmgr", "qmgr")
+	if err != nil {
+		return nil, err
+	}
+	dir := mqini.GetErrorLogDirectory(qm)
+	return mirrorLogWithCheckpoint(ctx, wg, filepath.Join(dir, "AMQERR01.json"), fromStart, mf, true, cp, errorLogRotation(dir)...)
+}
+
+// getCheckpointer returns a checkpointer for a mirrored log, using the policy for its source
+func getCheckpointer(source, name string) (*checkpointer, error) {
+	policy, err := getMirrorPolicy(source)
+	if err != nil {
+		return nil, err
+	}
+	return newCheckpointer(checkpointDir, name, policy), nil
+}
+
+// errorLogRotation returns the names given to the JSON error log in a directory when it rotates, from newest to oldest
+func errorLogRotation(dir string) []string {
+	return []string{filepath.Join(dir, "AMQERR02.json"), filepath.Join(dir, "AMQERR03.json")}
 }
 
 // mirrorHTPasswdLogs starts a goroutine to mirror the contents of the MQ HTPasswd authorization service's log
 func mirrorHTPasswdLogs(ctx context.Context, wg *sync.WaitGroup, name string, fromStart bool, mf mirrorFunc) (chan error, error) {
-	return mirrorLog(ctx, wg, "/var/mqm/errors/mqhtpass.json", false, mf, true)
+	cp, err := getCheckpointer("qmgr", "htpasswd")
+	if err != nil {
+		return nil, err
+	}
+	return mirrorLogWithCheckpoint(ctx, wg, "/var/mqm/errors/mqhtpass.json", false, mf, true, cp)
 }
 
 // mirrorWebServerLogs starts a goroutine to mirror the contents of the Liberty web server messages.log
 func mirrorWebServerLogs(ctx context.Context, wg *sync.WaitGroup, name string, fromStart bool, mf mirrorFunc) (chan error, error) {
-	return mirrorLog(ctx, wg, "/var/mqm/web/installations/Installation1/servers/mqweb/logs/messages.log", fromStart, mf, true)
+	cp, err := getCheckpointer("web", "web")
+	if err != nil {
+		return nil, err
+	}
+	return mirrorLogWithCheckpoint(ctx, wg, "/var/mqm/web/installations/Installation1/servers/mqweb/logs/messages.log", fromStart, translateLibertyLogs(mf), true, cp)
 }
 
 func getDebug() bool {
@@ -225,44 +273,53 @@ func configureLogger(name string) (mirrorFunc, error) {
 	var err error
 	f := getLogFormat()
 	d := getDebug()
+	// The same filter is used for every console format
+	filter, filterErr := newLogFilter()
 	switch f {
 	case "json":
 		log, err = logger.NewLogger(os.Stderr, d, true, name)
 		if err != nil {
 			return nil, err
 		}
-		return func(msg string, isQMLog bool) bool {
-			arrLoggingConsoleExcludeIds := strings.Split(strings.ToUpper(os.Getenv("MQ_LOGGING_CONSOLE_EXCLUDE_ID")), ",")
-			if isExcludedMsgIdPresent(msg, arrLoggingConsoleExcludeIds) {
-				//If excluded id is present do not mirror it, return back
-				return false
+		if filterErr != nil {
+			return nil, filterErr
+		}
+		return configureLogSinks(name, newStructuredMirrorFunc(filter, func(msg string, obj map[string]interface{}) (string, error) {
+			if obj != nil {
+				return msg, nil
 			}
-			// Check if the message is JSON
-			if len(msg) > 0 && msg[0] == '{' {
-				obj, err := processLogMessage(msg)
-				if err == nil && isQMLog && filterQMLogMessage(obj) {
-					return false
-				}
-				if err != nil {
-					log.Printf("Failed to unmarshall JSON in log message - %v", msg)
-				} else {
-					fmt.Println(msg)
+			// The log being mirrored isn't JSON, so wrap it in a simple JSON message
+			b, err := json.Marshal(map[string]interface{}{"message": msg})
+			return string(b), err
+		}))
+	case logger.FormatLogfmt, logger.FormatECS, logger.FormatOTel:
+		log, err = logger.NewLoggerWithFormat(os.Stderr, d, f, name)
+		if err != nil {
+			return nil, err
+		}
+		if filterErr != nil {
+			return nil, filterErr
+		}
+		return configureLogSinks(name, newStructuredMirrorFunc(filter, func(msg string, obj map[string]interface{}) (string, error) {
+			if obj == nil {
+				// The log being mirrored isn't JSON, so wrap it in a simple message
+				obj = map[string]interface{}{
+					"message":      msg,
+					"ibm_datetime": time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
 				}
-			} else {
-				// The log being mirrored isn't JSON, so wrap it in a simple JSON message
-				// MQ error logs are usually JSON, but this is useful for Liberty logs - usually expect WLP_LOGGING_MESSAGE_FORMAT=JSON to be set when mirroring Liberty logs.
-				fmt.Printf("{\"message\":\"%s\"}\n", msg)
 			}
-			return true
-		}, nil
+			return logger.FormatEntry(obj, f)
+		}))
 	case "basic":
 		log, err = logger.NewLogger(os.Stderr, d, false, name)
 		if err != nil {
 			return nil, err
 		}
-		return func(msg string, isQMLog bool) bool {
-			arrLoggingConsoleExcludeIds := strings.Split(strings.ToUpper(os.Getenv("MQ_LOGGING_CONSOLE_EXCLUDE_ID")), ",")
-			if isExcludedMsgIdPresent(msg, arrLoggingConsoleExcludeIds) {
+		if filterErr != nil {
+			return nil, filterErr
+		}
+		return configureLogSinks(name, func(msg string, isQMLog bool) bool {
+			if filter.excludesLine(msg) {
 				//If excluded id is present do not mirror it, return back
 				return false
 			}
@@ -273,6 +330,9 @@ func configureLogger(name string) (mirrorFunc, error) {
 				if err == nil && isQMLog && filterQMLogMessage(obj) {
 					return false
 				}
+				if err == nil && !filter.allows(obj) {
+					return false
+				}
 				if err != nil {
 					log.Printf("Failed to unmarshall JSON in log message - %v", err)
 				} else {
@@ -280,11 +340,10 @@ func configureLogger(name string) (mirrorFunc, error) {
 				}
 			} else {
 				// The log being mirrored isn't JSON, so just print it.
-				// MQ error logs are usually JSON, but this is useful for Liberty logs - usually expect WLP_LOGGING_MESSAGE_FORMAT=JSON to be set when mirroring Liberty logs.
 				fmt.Println(msg)
 			}
 			return true
-		}, nil
+		})
 	default:
 		log, err = logger.NewLogger(os.Stdout, d, false, name)
 		if err != nil {
@@ -294,6 +353,83 @@ func configureLogger(name string) (mirrorFunc, error) {
 	}
 }
 
+// newStructuredMirrorFunc returns a mirrorFunc for the structured console log formats.  Mirrored JSON messages are
+// parsed and filtered, then the format function is called with the message and the parsed object, which is nil if
+// the message isn't JSON.
+func newStructuredMirrorFunc(filter *logFilter, format func(msg string, obj map[string]interface{}) (string, error)) mirrorFunc {
+	return func(msg string, isQMLog bool) bool {
+		if filter.excludesLine(msg) {
+			//If excluded id is present do not mirror it, return back
+			return false
+		}
+		var obj map[string]interface{}
+		// Check if the message is JSON
+		if len(msg) > 0 && msg[0] == '{' {
+			var err error
+			obj, err = processLogMessage(msg)
+			if err != nil {
+				log.Printf("Failed to unmarshall JSON in log message - %v", msg)
+				return true
+			}
+			if isQMLog && filterQMLogMessage(obj) {
+				return false
+			}
+			if !filter.allows(obj) {
+				return false
+			}
+		}
+		out, err := format(msg, obj)
+		if err != nil {
+			log.Printf("Failed to format log message - %v", err)
+		} else {
+			fmt.Println(out)
+		}
+		return true
+	}
+}
+
+// configureLogSinks configures forwarding of the container log and mirrored logs to the log sinks set in
+// environment variables.  Mirrored messages are forwarded before they are filtered for the console.
+func configureLogSinks(name string, mf mirrorFunc) (mirrorFunc, error) {
+	var err error
+	logForwarder, err = logsink.Configure(name, log)
+	if err != nil || logForwarder == nil {
+		return mf, err
+	}
+	log.AddHook(func(entry map[string]interface{}) {
+		logForwarder.Send(entry)
+	})
+	return func(msg string, isQMLog bool) bool {
+		forwardLogMessage(msg, isQMLog)
+		return mf(msg, isQMLog)
+	}, nil
+}
+
+// forwardLogMessage sends a mirrored message to the log sinks.  Messages which aren't JSON are wrapped in a
+// simple JSON message.
+func forwardLogMessage(msg string, isQMLog bool) {
+	if len(msg) > 0 && msg[0] == '{' {
+		obj, err := processLogMessage(msg)
+		if err == nil {
+			if !(isQMLog && filterQMLogMessage(obj)) {
+				logForwarder.Send(obj)
+			}
+			return
+		}
+	}
+	logForwarder.Send(logsink.Entry{
+		"message":      msg,
+		"ibm_datetime": time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+	})
+}
+
+// closeLogSinks sends any buffered log entries to the log sinks, and closes them
+func closeLogSinks() {
+	if logForwarder != nil {
+		logForwarder.Close()
+	}
+}
+
 func processLogMessage(msg string) (map[string]interface{}, error) {
 	var obj map[string]interface{}
 	err := json.Unmarshal([]byte(msg), &obj)
@@ -319,7 +455,7 @@ func isExcludedMsgIdPresent(msg string, envExcludeIds []string) bool {
 }
 
 func logDiagnostics() {
-	if getDebug() {
+	if log.Enabled(logger.LevelDebug) {
 		log.Debug("--- Start Diagnostics ---")
 
 		// show the directory ownership/permissions
@@ -384,7 +520,10 @@ func isLogConsoleSourceValid() bool {
 			retValue = true
 		//If invalid entry arrives in-between/anywhere, just return false, there is no turning back
 		default:
-			return false
+			if !isDeclaredLogSource(src) {
+				return false
+			}
+			retValue = true
 		}
 	}
 
@@ -425,6 +564,11 @@ func checkLogSourceForMirroring(source string) bool {
 				}
 				return true
 			}
+		default:
+			//Other sources are mirrored if they are declared
+			if strings.TrimSpace(arr) == source && isDeclaredLogSource(source) {
+				return true
+			}
 		}
 	}
 	return false
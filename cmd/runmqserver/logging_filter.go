@@ -0,0 +1,175 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Severities of mirrored log messages, in increasing order
+const (
+	severityUnknown = iota
+	severityInfo
+	severityWarning
+	severityError
+	severitySevere
+)
+
+// severityNames are the values allowed for MQ_LOGGING_CONSOLE_LEVEL
+var severityNames = map[string]int{
+	"info":    severityInfo,
+	"warning": severityWarning,
+	"error":   severityError,
+	"severe":  severitySevere,
+}
+
+// logFilter decides which mirrored log messages are printed on the console
+type logFilter struct {
+	// excludeIDs are matched against the whole of the log line
+	excludeIDs   []string
+	includeIDs   map[string]bool
+	minSeverity  int
+	excludeRegex *regexp.Regexp
+	includeRegex *regexp.Regexp
+}
+
+// newLogFilter returns a log filter configured from the MQ_LOGGING_CONSOLE_* environment variables
+func newLogFilter() (*logFilter, error) {
+	f := &logFilter{
+		excludeIDs:  strings.Split(strings.ToUpper(os.Getenv("MQ_LOGGING_CONSOLE_EXCLUDE_ID")), ","),
+		includeIDs:  make(map[string]bool),
+		minSeverity: severityInfo,
+	}
+	for _, id := range strings.Split(strings.ToUpper(os.Getenv("MQ_LOGGING_CONSOLE_INCLUDE_ID")), ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			f.includeIDs[id] = true
+		}
+	}
+	level := strings.ToLower(strings.TrimSpace(os.Getenv("MQ_LOGGING_CONSOLE_LEVEL")))
+	if level != "" {
+		severity, ok := severityNames[level]
+		if !ok {
+			return nil, fmt.Errorf("Invalid value for MQ_LOGGING_CONSOLE_LEVEL: %v.  Allowed levels are 'info', 'warning', 'error' and 'severe'", level)
+		}
+		f.minSeverity = severity
+	}
+	var err error
+	f.excludeRegex, err = compileFilterRegex("MQ_LOGGING_CONSOLE_EXCLUDE_REGEX")
+	if err != nil {
+		return nil, err
+	}
+	f.includeRegex, err = compileFilterRegex("MQ_LOGGING_CONSOLE_INCLUDE_REGEX")
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// compileFilterRegex compiles the regular expression in the specified environment variable, if it is set
+func compileFilterRegex(name string) (*regexp.Regexp, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid value for %v: %v", name, err)
+	}
+	return re, nil
+}
+
+// excludesLine returns true if the log line contains one of the IDs in MQ_LOGGING_CONSOLE_EXCLUDE_ID.
+// This is checked before the line is parsed, and applies to lines which aren't JSON.
+func (f *logFilter) excludesLine(msg string) bool {
+	return isExcludedMsgIdPresent(msg, f.excludeIDs)
+}
+
+// allows returns true if a parsed JSON log message should be printed on the console.  Messages which match
+// the exclude regular expression are never printed.  Messages with an included ID, or which match the include
+// regular expression are always printed, and other messages are printed if they are at least as severe as the
+// minimum level.  Messages with an unknown severity are always printed.
+func (f *logFilter) allows(obj map[string]interface{}) bool {
+	fields := filterFields(obj)
+	if f.excludeRegex != nil && matchesAny(f.excludeRegex, fields) {
+		return false
+	}
+	id, _ := obj["ibm_messageId"].(string)
+	if f.includeIDs[strings.ToUpper(id)] {
+		return true
+	}
+	if f.includeRegex != nil && matchesAny(f.includeRegex, fields) {
+		return true
+	}
+	severity := getMessageSeverity(obj)
+	return severity == severityUnknown || severity >= f.minSeverity
+}
+
+// filterFields returns the fields of a log message which are matched by regular expressions: the message ID,
+// followed by the comment and arithmetic inserts
+func filterFields(obj map[string]interface{}) []string {
+	fields := []string{}
+	if id, ok := obj["ibm_messageId"].(string); ok {
+		fields = append(fields, id)
+	}
+	for k, v := range obj {
+		if strings.HasPrefix(k, "ibm_commentInsert") || strings.HasPrefix(k, "ibm_arithInsert") {
+			fields = append(fields, fmt.Sprintf("%v", v))
+		}
+	}
+	return fields
+}
+
+func matchesAny(re *regexp.Regexp, fields []string) bool {
+	for _, field := range fields {
+		if re.MatchString(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMessageSeverity returns the severity of a log message, from the suffix of the message ID (for example
+// AMQ9999E), or from the log level if the message has no ID
+func getMessageSeverity(obj map[string]interface{}) int {
+	if id, ok := obj["ibm_messageId"].(string); ok && len(id) > 0 {
+		switch strings.ToUpper(id)[len(id)-1] {
+		case 'I', 'A':
+			return severityInfo
+		case 'W':
+			return severityWarning
+		case 'E':
+			return severityError
+		case 'S':
+			return severitySevere
+		}
+	}
+	level, _ := obj["loglevel"].(string)
+	switch strings.ToUpper(level) {
+	case "INFO", "AUDIT", "EVENT", "ENTRY", "EXIT", "FINE", "FINER", "FINEST", "DEBUG", "TRACE":
+		return severityInfo
+	case "WARNING", "WARN":
+		return severityWarning
+	case "ERROR":
+		return severityError
+	case "SEVERE", "FATAL":
+		return severitySevere
+	}
+	return severityUnknown
+}
@@ -0,0 +1,77 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// logLevelsFile can be written while the container is running, to change the log levels
+const logLevelsFile = "/run/runmqserver/log-levels"
+
+// logLevelsInterval is how often the log levels file is checked for changes
+const logLevelsInterval = 2 * time.Second
+
+// logLevelWatcher applies changes to the log levels file, and restores the log levels after SIGUSR2
+var logLevelWatcher *logger.LevelWatcher
+
+// getLogLevels returns the log levels set in MQ_LOGGING_LEVEL, or "debug" if DEBUG is set
+func getLogLevels() string {
+	levels := strings.TrimSpace(os.Getenv("MQ_LOGGING_LEVEL"))
+	if levels != "" {
+		return levels
+	}
+	if getDebug() {
+		return logger.LevelDebug.String()
+	}
+	return logger.LevelInfo.String()
+}
+
+// configureLogLevels sets the log levels from MQ_LOGGING_LEVEL, and starts watching the log levels file.
+// The levels in the file are used instead of MQ_LOGGING_LEVEL while the file exists.
+func configureLogLevels(stop <-chan struct{}) error {
+	levels := getLogLevels()
+	err := log.SetLevels(levels)
+	if err != nil {
+		return fmt.Errorf("Invalid value for MQ_LOGGING_LEVEL: %v", err)
+	}
+	logLevelWatcher = logger.NewLevelWatcher(log, logLevelsFile, levels)
+	logLevelWatcher.Restore()
+	go logLevelWatcher.Run(logLevelsInterval, stop)
+	return nil
+}
+
+// setDebugLogLevels logs debug messages from every component, until the log levels are restored
+func setDebugLogLevels() {
+	err := log.SetLevels(logger.LevelDebug.String())
+	if err != nil {
+		log.Errorf("Failed to set log levels: %v", err)
+		return
+	}
+	log.Printf("Log levels changed to %s", log.Levels())
+}
+
+// restoreLogLevels sets the log levels back to those in the log levels file, or in MQ_LOGGING_LEVEL
+func restoreLogLevels() {
+	if logLevelWatcher != nil {
+		logLevelWatcher.Restore()
+	}
+}
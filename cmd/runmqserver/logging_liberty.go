@@ -0,0 +1,131 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"time"
+)
+
+// libertyServerName is the name of the Liberty server which runs the MQ web server
+const libertyServerName = "mqweb"
+
+// libertyLineRegexp matches a line in Liberty's basic messages.log format, for example
+// "[6/26/23, 10:00:00:123 UTC] 00000001 com.ibm.ws.kernel.launch.internal.FrameworkManager A CWWKE0001I: The server mqweb has been launched."
+var libertyLineRegexp = regexp.MustCompile(`^\[([^\]]+)\]\s+([0-9a-fA-F]{8})\s+(\S+)\s+([AIWEFORD])\s(.*)$`)
+
+// libertyMessageIDRegexp matches the message ID at the start of a Liberty message
+var libertyMessageIDRegexp = regexp.MustCompile(`^([A-Z][A-Z0-9]{3,4}\d{4}[IAWEF]):`)
+
+// libertyMillisRegexp matches the milliseconds in a messages.log date, which follow a colon instead of a period
+var libertyMillisRegexp = regexp.MustCompile(`(\d{2}:\d{2}:\d{2}):(\d{3})\b`)
+
+// libertyDateLayouts are the layouts used for dates in messages.log, with the default and ISO date formats
+var libertyDateLayouts = []string{
+	"1/2/06, 15:04:05.000 MST",
+	"1/2/06 15:04:05.000 MST",
+	"2006-01-02T15:04:05.000-0700",
+}
+
+// libertyLevels maps the level characters in messages.log to the levels used in Liberty's JSON logs
+var libertyLevels = map[string]string{
+	"A": "AUDIT",
+	"I": "INFO",
+	"W": "WARNING",
+	"E": "ERROR",
+	"F": "FATAL",
+	"O": "SystemOut",
+	"R": "SystemErr",
+	"D": "DETAIL",
+}
+
+// parseLibertyDate parses the date of a line in messages.log
+func parseLibertyDate(s string) (time.Time, bool) {
+	s = libertyMillisRegexp.ReplaceAllString(s, "$1.$2")
+	for _, layout := range libertyDateLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// translateLibertyLogs returns a mirror function which converts lines from Liberty's basic messages.log format
+// into JSON messages with the same fields as Liberty's JSON format, before calling the specified mirror
+// function.  Lines which are already JSON are passed on unchanged.  Lines which continue a message, such as
+// stack traces, use the time, level and thread of the message they continue.
+func translateLibertyLogs(mf mirrorFunc) mirrorFunc {
+	// #nosec G104 - the host name is left empty if it is not available
+	hostname, _ := os.Hostname()
+	var previous map[string]interface{}
+	return func(msg string, isQMLog bool) bool {
+		if len(msg) > 0 && msg[0] == '{' {
+			return mf(msg, isQMLog)
+		}
+		obj, continued := parseLibertyLine(msg, previous)
+		if obj == nil {
+			return mf(msg, isQMLog)
+		}
+		obj["host"] = hostname
+		obj["ibm_serverName"] = libertyServerName
+		obj["type"] = "liberty_message"
+		if !continued {
+			previous = obj
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			log.Debugf("Failed to convert web server log message to JSON: %v", err)
+			return mf(msg, isQMLog)
+		}
+		return mf(string(b), isQMLog)
+	}
+}
+
+// parseLibertyLine parses a line in Liberty's basic messages.log format.  A line which doesn't start a new
+// message is returned as a continuation of the previous message, if there is one.
+func parseLibertyLine(line string, previous map[string]interface{}) (map[string]interface{}, bool) {
+	match := libertyLineRegexp.FindStringSubmatch(line)
+	if match == nil {
+		if previous == nil {
+			return nil, false
+		}
+		obj := map[string]interface{}{"message": line}
+		for _, k := range []string{"ibm_datetime", "loglevel", "ibm_threadId", "module"} {
+			if v, ok := previous[k]; ok {
+				obj[k] = v
+			}
+		}
+		return obj, true
+	}
+	t, ok := parseLibertyDate(match[1])
+	if !ok {
+		t = time.Now()
+	}
+	obj := map[string]interface{}{
+		"ibm_datetime": t.Format("2006-01-02T15:04:05.000Z07:00"),
+		"ibm_threadId": match[2],
+		"module":       match[3],
+		"loglevel":     libertyLevels[match[4]],
+		"message":      match[5],
+	}
+	if id := libertyMessageIDRegexp.FindStringSubmatch(match[5]); id != nil {
+		obj["ibm_messageId"] = id[1]
+	}
+	return obj, false
+}
@@ -0,0 +1,201 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultLogSourcesFile is the file which declares extra log sources, used if MQ_LOGGING_SOURCES_FILE is not set
+const defaultLogSourcesFile = "/etc/mqm/logging-sources.yaml"
+
+// Formats of the lines in a declared log source
+const (
+	logSourceJSON  = "json"
+	logSourcePlain = "plain"
+)
+
+// logSourceNameRegexp matches the names allowed for declared log sources
+var logSourceNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// logSource is a log file which can be mirrored, by including its name in MQ_LOGGING_CONSOLE_SOURCE
+type logSource struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+	Tag    string `yaml:"tag"`
+	// Rotated are the names the file is given when the log rotates, from newest to oldest
+	Rotated []string `yaml:"rotated"`
+}
+
+type logSourcesFile struct {
+	Sources map[string]logSource `yaml:"sources"`
+}
+
+// defaultLogSources are the log sources which can be mirrored without being declared.  Their paths can be
+// changed by declaring a source with the same name.  Other log files, such as those written by exits or by
+// MQIPT, must be declared with the path they are written to.
+var defaultLogSources = map[string]logSource{
+	"htpasswd": {Path: "/var/mqm/errors/mqhtpass.json", Format: logSourceJSON},
+}
+
+// logSources are the log sources which can be mirrored, in addition to "qmgr" and "web"
+var logSources = copyLogSources(defaultLogSources)
+
+func copyLogSources(sources map[string]logSource) map[string]logSource {
+	c := make(map[string]logSource, len(sources))
+	for name, source := range sources {
+		c[name] = source
+	}
+	return c
+}
+
+// configureLogSources loads the log sources declared in the file set in MQ_LOGGING_SOURCES_FILE, or in the
+// default file if it exists
+func configureLogSources() error {
+	file := os.Getenv("MQ_LOGGING_SOURCES_FILE")
+	required := file != ""
+	if !required {
+		file = defaultLogSourcesFile
+	}
+	// #nosec G304 - the file name is a defined constant, or is set by the administrator of the container
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("Failed to read log sources file %s: %v", file, err)
+	}
+	declared, err := parseLogSources(buf)
+	if err != nil {
+		return fmt.Errorf("Failed to parse log sources file %s: %v", file, err)
+	}
+	sources := copyLogSources(defaultLogSources)
+	for name, source := range declared {
+		sources[name] = source
+	}
+	logSources = sources
+	return nil
+}
+
+// parseLogSources parses and validates the contents of a log sources file
+func parseLogSources(buf []byte) (map[string]logSource, error) {
+	var sourcesFile logSourcesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&sourcesFile)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	for name, source := range sourcesFile.Sources {
+		if !logSourceNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("Log source name '%s' must contain only lower case letters, digits, '-' and '_'", name)
+		}
+		if name == "qmgr" || name == "web" {
+			return nil, fmt.Errorf("Log source name '%s' is reserved", name)
+		}
+		if !filepath.IsAbs(source.Path) {
+			return nil, fmt.Errorf("Log source '%s' must have an absolute path", name)
+		}
+		switch source.Format {
+		case "":
+			source.Format = logSourcePlain
+		case logSourceJSON, logSourcePlain:
+		default:
+			return nil, fmt.Errorf("Log source '%s' has format '%s'.  Allowed formats are 'json' and 'plain'", name, source.Format)
+		}
+		sourcesFile.Sources[name] = source
+	}
+	return sourcesFile.Sources, nil
+}
+
+// logSourceNames returns the names of the log sources which can be mirrored, in addition to "qmgr" and "web"
+func logSourceNames() []string {
+	names := make([]string, 0, len(logSources))
+	for name := range logSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mirrorLogSource mirrors a log source, adding its tag to every message
+func mirrorLogSource(ctx context.Context, wg *sync.WaitGroup, name string, mf mirrorFunc) (chan error, error) {
+	source, ok := logSources[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown log source: %v", name)
+	}
+	cp, err := getCheckpointer(name, "source-"+name)
+	if err != nil {
+		return nil, err
+	}
+	tag := source.Tag
+	if tag == "" {
+		tag = name
+	}
+	return mirrorLogWithCheckpoint(ctx, wg, source.Path, false, tagLogMessages(tag, source.Format, mf), false, cp, source.Rotated...)
+}
+
+// tagLogMessages returns a mirror function which adds a source tag to each message, before calling the
+// specified mirror function.  Lines in plain format are wrapped in a JSON message.
+func tagLogMessages(tag string, format string, mf mirrorFunc) mirrorFunc {
+	return func(msg string, isQMLog bool) bool {
+		var obj map[string]interface{}
+		if format == logSourceJSON && len(msg) > 0 && msg[0] == '{' {
+			var err error
+			obj, err = processLogMessage(msg)
+			if err != nil {
+				obj = nil
+			}
+		}
+		if obj == nil {
+			obj = map[string]interface{}{"message": msg}
+		}
+		// The time is required by the basic format
+		if _, ok := obj["ibm_datetime"].(string); !ok {
+			obj["ibm_datetime"] = time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+		}
+		obj["ibm_logSource"] = tag
+		if _, ok := obj["type"]; !ok {
+			obj["type"] = tag
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			log.Debugf("Failed to add source tag to log message: %v", err)
+			return mf(msg, isQMLog)
+		}
+		return mf(string(b), isQMLog)
+	}
+}
+
+// isDeclaredLogSource returns true if the name is a log source which can be mirrored, other than "qmgr" and "web"
+func isDeclaredLogSource(name string) bool {
+	_, ok := logSources[strings.TrimSpace(name)]
+	return ok
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -137,3 +138,227 @@ func TestIsExcludedMsgIDPresent(t *testing.T) {
 		}
 	}
 }
+
+// This test covers for function logFilter.allows()
+var mqLogFilterTests = []struct {
+	testNum      int
+	level        string
+	includeIDs   string
+	includeRegex string
+	excludeRegex string
+	logEntry     string
+	expectedRet  bool
+}{
+	{1, "", "", "", "", "{\"ibm_messageId\":\"AMQ5051I\",\"loglevel\":\"INFO\"}", true},
+	{2, "warning", "", "", "", "{\"ibm_messageId\":\"AMQ5051I\",\"loglevel\":\"INFO\"}", false},
+	{3, "warning", "", "", "", "{\"ibm_messageId\":\"AMQ9999W\",\"loglevel\":\"WARNING\"}", true},
+	{4, "WARNING", "", "", "", "{\"ibm_messageId\":\"AMQ9999E\",\"loglevel\":\"ERROR\"}", true},
+	{5, "severe", "", "", "", "{\"ibm_messageId\":\"AMQ9999E\",\"loglevel\":\"ERROR\"}", false},
+	{6, "error", "", "", "", "{\"loglevel\":\"SEVERE\",\"message\":\"No message ID\"}", true},
+	{7, "error", "", "", "", "{\"loglevel\":\"AUDIT\",\"message\":\"No message ID\"}", false},
+	{8, "error", "", "", "", "{\"message\":\"Unknown severity\"}", true},
+	{9, "warning", "amq5026i, AMQ5051I", "", "", "{\"ibm_messageId\":\"AMQ5026I\",\"loglevel\":\"INFO\"}", true},
+	{10, "warning", "", "^AMQ50", "", "{\"ibm_messageId\":\"AMQ5026I\",\"loglevel\":\"INFO\"}", true},
+	{11, "warning", "", "SYSTEM\\.LISTENER", "", "{\"ibm_messageId\":\"AMQ5026I\",\"ibm_commentInsert1\":\"SYSTEM.LISTENER.TCP.1\"}", true},
+	{12, "", "", "", "^APP\\.", "{\"ibm_messageId\":\"AMQ9999E\",\"ibm_commentInsert1\":\"APP.SVRCONN\"}", false},
+	{13, "", "", "", "^1414$", "{\"ibm_messageId\":\"AMQ5026I\",\"ibm_arithInsert1\":1414}", false},
+	{14, "", "AMQ9999E", "", "AMQ9999E", "{\"ibm_messageId\":\"AMQ9999E\"}", false},
+	{15, "severe", "", "", "", "{\"ibm_messageId\":\"CWWKE0701S\",\"loglevel\":\"SEVERE\"}", true},
+}
+
+func TestLogFilterAllows(t *testing.T) {
+	for _, filterTest := range mqLogFilterTests {
+		t.Setenv("MQ_LOGGING_CONSOLE_LEVEL", filterTest.level)
+		t.Setenv("MQ_LOGGING_CONSOLE_INCLUDE_ID", filterTest.includeIDs)
+		t.Setenv("MQ_LOGGING_CONSOLE_INCLUDE_REGEX", filterTest.includeRegex)
+		t.Setenv("MQ_LOGGING_CONSOLE_EXCLUDE_REGEX", filterTest.excludeRegex)
+		filter, err := newLogFilter()
+		if err != nil {
+			t.Fatalf("%v. Unexpected error from newLogFilter(): %v", filterTest.testNum, err)
+		}
+		obj, err := processLogMessage(filterTest.logEntry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		retVal := filter.allows(obj)
+		if retVal != filterTest.expectedRet {
+			t.Errorf("%v. Expected return value from allows() is %v for %v, got %v\n", filterTest.testNum, filterTest.expectedRet, filterTest.logEntry, retVal)
+		}
+	}
+}
+
+func TestLogFilterInvalid(t *testing.T) {
+	t.Setenv("MQ_LOGGING_CONSOLE_LEVEL", "verbose")
+	_, err := newLogFilter()
+	if err == nil {
+		t.Error("Expected an error from newLogFilter() for an invalid level")
+	}
+	t.Setenv("MQ_LOGGING_CONSOLE_LEVEL", "")
+	t.Setenv("MQ_LOGGING_CONSOLE_EXCLUDE_REGEX", "AMQ(")
+	_, err = newLogFilter()
+	if err == nil {
+		t.Error("Expected an error from newLogFilter() for an invalid regular expression")
+	}
+}
+
+// This test covers for function parseLogSources()
+var mqLogSourcesFileTests = []struct {
+	testNum   int
+	contents  string
+	expectErr bool
+}{
+	{1, "sources:\n  myexit:\n    path: /var/mqm/exits/myexit.log\n    format: json\n    tag: exit\n", false},
+	{2, "sources:\n  audit:\n    path: /var/mqm/trace/audit.log\n", false},
+	{3, "sources:\n  myexit:\n    path: exits/myexit.log\n", true},
+	{4, "sources:\n  myexit:\n    path: /var/mqm/exits/myexit.log\n    format: xml\n", true},
+	{5, "sources:\n  qmgr:\n    path: /var/mqm/errors/AMQERR01.json\n", true},
+	{6, "sources:\n  My Exit:\n    path: /var/mqm/exits/myexit.log\n", true},
+	{7, "sources:\n  myexit:\n    file: /var/mqm/exits/myexit.log\n", true},
+	{8, "", false},
+}
+
+func TestParseLogSources(t *testing.T) {
+	for _, sourcesTest := range mqLogSourcesFileTests {
+		_, err := parseLogSources([]byte(sourcesTest.contents))
+		if (err != nil) != sourcesTest.expectErr {
+			t.Errorf("%v. Expected error=%v from parseLogSources(), got %v", sourcesTest.testNum, sourcesTest.expectErr, err)
+		}
+	}
+}
+
+func TestDeclaredLogSources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logging-sources.yaml")
+	err := os.WriteFile(file, []byte("sources:\n  myexit:\n    path: /var/mqm/exits/myexit.log\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MQ_LOGGING_SOURCES_FILE", file)
+	defer func() {
+		logSources = copyLogSources(defaultLogSources)
+	}()
+	err = configureLogSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MQ_LOGGING_CONSOLE_SOURCE", "qmgr,myexit,htpasswd")
+	if !isLogConsoleSourceValid() {
+		t.Errorf("Expected declared and default log sources to be valid")
+	}
+	for source, expected := range map[string]bool{"qmgr": true, "myexit": true, "htpasswd": true, "mqipt": false, "audit": false, "web": false} {
+		if checkLogSourceForMirroring(source) != expected {
+			t.Errorf("Expected checkLogSourceForMirroring(%v) to return %v", source, expected)
+		}
+	}
+	if logSources["myexit"].Format != logSourcePlain {
+		t.Errorf("Expected default format to be plain; got %v", logSources["myexit"].Format)
+	}
+}
+
+func TestTagLogMessages(t *testing.T) {
+	var out []map[string]interface{}
+	mf := func(msg string, isQMLog bool) bool {
+		obj, err := processLogMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, obj)
+		return true
+	}
+	tagLogMessages("exit", logSourcePlain, mf)("{\"message\":\"Plain\"}", false)
+	tagLogMessages("exit", logSourceJSON, mf)("{\"message\":\"JSON\",\"type\":\"exit_log\",\"ibm_datetime\":\"2023-06-24T00:00:00.000Z\"}", false)
+	if len(out) != 2 {
+		t.Fatalf("Expected 2 messages; got %v", len(out))
+	}
+	if out[0]["message"] != "{\"message\":\"Plain\"}" || out[0]["ibm_logSource"] != "exit" || out[0]["type"] != "exit" || out[0]["ibm_datetime"] == nil {
+		t.Errorf("Expected plain line to be wrapped and tagged; got %v", out[0])
+	}
+	if out[1]["message"] != "JSON" || out[1]["ibm_logSource"] != "exit" || out[1]["type"] != "exit_log" || out[1]["ibm_datetime"] != "2023-06-24T00:00:00.000Z" {
+		t.Errorf("Expected JSON message to be tagged; got %v", out[1])
+	}
+}
+
+func TestTranslateLibertyLogs(t *testing.T) {
+	var out []map[string]interface{}
+	mf := func(msg string, isQMLog bool) bool {
+		obj, err := processLogMessage(msg)
+		if err != nil {
+			t.Fatalf("Expected valid JSON; got %v: %v", msg, err)
+		}
+		out = append(out, obj)
+		return true
+	}
+	translate := translateLibertyLogs(mf)
+	translate("[6/26/23, 10:00:00:123 UTC] 00000030 com.ibm.ws.kernel.launch.internal.FrameworkManager  A CWWKE0001I: The server \"mqweb\" has been launched.", true)
+	translate("\tat com.ibm.example.Class.method(Class.java:10) \\ continued", true)
+	translate("{\"message\":\"JSON\",\"type\":\"liberty_message\"}", true)
+	if len(out) != 3 {
+		t.Fatalf("Expected 3 messages; got %v", len(out))
+	}
+	expected := map[string]interface{}{
+		"ibm_datetime":   "2023-06-26T10:00:00.123Z",
+		"ibm_threadId":   "00000030",
+		"module":         "com.ibm.ws.kernel.launch.internal.FrameworkManager",
+		"loglevel":       "AUDIT",
+		"ibm_messageId":  "CWWKE0001I",
+		"ibm_serverName": "mqweb",
+		"type":           "liberty_message",
+		"message":        "CWWKE0001I: The server \"mqweb\" has been launched.",
+	}
+	for k, v := range expected {
+		if out[0][k] != v {
+			t.Errorf("Expected %v=%v; got %v", k, v, out[0][k])
+		}
+	}
+	if out[1]["message"] != "\tat com.ibm.example.Class.method(Class.java:10) \\ continued" || out[1]["loglevel"] != "AUDIT" || out[1]["ibm_datetime"] != "2023-06-26T10:00:00.123Z" {
+		t.Errorf("Expected continuation line to use the time and level of the previous message; got %v", out[1])
+	}
+	if out[2]["message"] != "JSON" {
+		t.Errorf("Expected JSON message to be unchanged; got %v", out[2])
+	}
+}
+
+var structuredMirrorTests = []struct {
+	name      string
+	level     string
+	excludeID string
+	msg       string
+	mirrored  bool
+	formatted bool
+	parsed    bool
+}{
+	{"Plain", "", "", "Plain message", true, true, false},
+	{"JSON", "", "", "{\"ibm_messageId\":\"AMQ5026I\",\"loglevel\":\"INFO\"}", true, true, true},
+	{"InvalidJSON", "", "", "{\"ibm_messageId\":", true, false, false},
+	{"ExcludedID", "", "AMQ5026I", "{\"ibm_messageId\":\"AMQ5026I\",\"loglevel\":\"INFO\"}", false, false, false},
+	{"BelowLevel", "error", "", "{\"ibm_messageId\":\"AMQ5026I\",\"loglevel\":\"INFO\"}", false, false, false},
+}
+
+func TestStructuredMirrorFunc(t *testing.T) {
+	for _, test := range structuredMirrorTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("MQ_LOGGING_CONSOLE_LEVEL", test.level)
+			t.Setenv("MQ_LOGGING_CONSOLE_EXCLUDE_ID", test.excludeID)
+			filter, err := newLogFilter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted := false
+			parsed := false
+			mf := newStructuredMirrorFunc(filter, func(msg string, obj map[string]interface{}) (string, error) {
+				formatted = true
+				parsed = obj != nil
+				return msg, nil
+			})
+			mirrored := mf(test.msg, false)
+			if mirrored != test.mirrored {
+				t.Errorf("Expected mirrored=%v; got %v", test.mirrored, mirrored)
+			}
+			if formatted != test.formatted {
+				t.Errorf("Expected formatted=%v; got %v", test.formatted, formatted)
+			}
+			if parsed != test.parsed {
+				t.Errorf("Expected parsed object=%v; got %v", test.parsed, parsed)
+			}
+		})
+	}
+}
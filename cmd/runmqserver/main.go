@@ -22,10 +22,13 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ibm-messaging/mq-container/internal/fips"
 	"github.com/ibm-messaging/mq-container/internal/ha"
+	"github.com/ibm-messaging/mq-container/internal/health"
 	"github.com/ibm-messaging/mq-container/internal/metrics"
 	"github.com/ibm-messaging/mq-container/internal/ready"
 	"github.com/ibm-messaging/mq-container/internal/tls"
@@ -46,6 +49,14 @@ func doMain() error {
 		logTermination(err)
 		return err
 	}
+	defer closeLogSinks()
+	stopLogLevels := make(chan struct{})
+	defer close(stopLogLevels)
+	err = configureLogLevels(stopLogLevels)
+	if err != nil {
+		logTermination(err)
+		return err
+	}
 
 	// Check whether they only want debug info
 	if *infoFlag {
@@ -73,6 +84,7 @@ func doMain() error {
 		logTermination(err)
 		return err
 	}
+	setStartupPhase(ready.PhaseLicense)
 	accepted, err := checkLicense()
 	if err != nil {
 		logTerminationf("Error checking license acceptance: %v", err)
@@ -98,6 +110,7 @@ func doMain() error {
 		}
 	}
 
+	setStartupPhase(ready.PhaseVolumes)
 	err = createVolume("/mnt/mqm/data")
 	if err != nil {
 		logTermination(err)
@@ -114,6 +127,7 @@ func doMain() error {
 		return err
 	}
 
+	setStartupPhase(ready.PhaseCrtmqdir)
 	enableTraceCrtmqdir := os.Getenv("MQ_ENABLE_TRACE_CRTMQDIR")
 	if enableTraceCrtmqdir == "true" || enableTraceCrtmqdir == "1" {
 		err = startMQTrace()
@@ -145,10 +159,12 @@ func doMain() error {
 	// Print out versioning information
 	logVersionInfo()
 
+	setStartupPhase(ready.PhaseTLS)
+
 	// Determine FIPS compliance level
 	fips.ProcessFIPSType(log)
 
-	keyLabel, defaultCmsKeystore, defaultP12Truststore, err := tls.ConfigureDefaultTLSKeystores()
+	keyLabel, defaultCmsKeystore, defaultP12Truststore, err := tls.ConfigureDefaultTLSKeystores(log.With("component", "tls"))
 	if err != nil {
 		logTermination(err)
 		return err
@@ -160,9 +176,27 @@ func doMain() error {
 		return err
 	}
 
+	tlsReloadInterval, err := getTLSReloadInterval()
+	if err != nil {
+		logTermination(err)
+		return err
+	}
+
+	certExpiryWarningDays, err := getCertExpiryWarningDays()
+	if err != nil {
+		logTermination(err)
+		return err
+	}
+
+	err = configureLogSources()
+	if err != nil {
+		logTermination(err)
+		return err
+	}
+
 	//Validate MQ_LOG_CONSOLE_SOURCE variable
 	if !isLogConsoleSourceValid() {
-		log.Println("One or more invalid value is provided for MQ_LOGGING_CONSOLE_SOURCE. Allowed values are 'qmgr' & 'web' in csv format")
+		log.Printf("One or more invalid value is provided for MQ_LOGGING_CONSOLE_SOURCE. Allowed values are 'qmgr', 'web' & '%v' in csv format", strings.Join(logSourceNames(), "', '"))
 	}
 
 	var wg sync.WaitGroup
@@ -176,6 +210,12 @@ func doMain() error {
 		cancelMirror()
 	}()
 
+	err = health.Start(name, log)
+	if err != nil {
+		logTermination(err)
+		return err
+	}
+
 	//For mirroring web server logs if source variable is set
 	if checkLogSourceForMirroring("web") {
 		// Always log from the end of the web server messages.log, because the log rotation should happen as soon as the web server starts
@@ -186,13 +226,15 @@ func doMain() error {
 		}
 	}
 
-	err = postInit(name, keyLabel, defaultP12Truststore)
+	setStartupPhase(ready.PhaseWebConfig)
+	webKeystore, err := postInit(name, keyLabel, defaultP12Truststore)
 	if err != nil {
 		logTermination(err)
 		return err
 	}
 
 	if os.Getenv("MQ_NATIVE_HA") == "true" {
+		setStartupPhase(ready.PhaseNativeHA)
 		err = ha.ConfigureNativeHA(log)
 		if err != nil {
 			logTermination(err)
@@ -203,6 +245,13 @@ func doMain() error {
 	// Post FIPS initialization processing
 	fips.PostInit(log)
 
+	setStartupPhase(ready.PhaseCrtmqm)
+	err = configureListeners()
+	if err != nil {
+		logTermination(err)
+		return err
+	}
+
 	enableTraceCrtmqm := os.Getenv("MQ_ENABLE_TRACE_CRTMQM")
 	if enableTraceCrtmqm == "true" || enableTraceCrtmqm == "1" {
 		err = startMQTrace()
@@ -241,6 +290,16 @@ func doMain() error {
 			logTermination(err)
 			return err
 		}
+
+		//Report FDC files created while the queue manager runs
+		fdcMode, err := getFDCMode()
+		if err != nil {
+			logTermination(err)
+			return err
+		}
+		if fdcMode != fdcDisabled {
+			mirrorFDCs(ctx, &wg, fdcDir, fdcMode == fdcHeader, mf)
+		}
 	}
 
 	if *devFlag {
@@ -251,12 +310,28 @@ func doMain() error {
 		}
 	}
 
+	//Mirror other log sources, if they are included in the source variable
+	for _, source := range logSourceNames() {
+		if source == "htpasswd" && *devFlag {
+			// Already mirrored
+			continue
+		}
+		if checkLogSourceForMirroring(source) {
+			_, err = mirrorLogSource(ctx, &wg, source, mf)
+			if err != nil {
+				logTermination(err)
+				return err
+			}
+		}
+	}
+
 	err = updateCommandLevel()
 	if err != nil {
 		logTermination(err)
 		return err
 	}
 
+	setStartupPhase(ready.PhaseStrmqm)
 	enableTraceStrmqm := os.Getenv("MQ_ENABLE_TRACE_STRMQM")
 	if enableTraceStrmqm == "true" || enableTraceStrmqm == "1" {
 		err = startMQTrace()
@@ -290,11 +365,24 @@ func doMain() error {
 		}
 	}
 
+	if os.Getenv("MQ_NATIVE_HA") == "true" {
+		go monitorNativeHAStatus(ctx, name)
+	}
+
+	// Start monitoring before watching for changes, so that reloaded certificates are checked straight away
+	monitorCertificateExpiry(ctx, webKeystore, defaultP12Truststore.Password, certExpiryWarningDays)
+
+	if tlsReloadInterval > 0 {
+		go watchTLS(ctx, name, keyLabel, defaultP12Truststore.Password, *devFlag, webKeystore, tlsReloadInterval)
+	}
+
 	enableMetrics := os.Getenv("MQ_ENABLE_METRICS")
 	if enableMetrics == "true" || enableMetrics == "1" {
-		go metrics.GatherMetrics(name, log)
+		setStartupPhase(ready.PhaseMetrics)
+		go metrics.GatherMetrics(name, keyLabel, log)
 	} else {
 		log.Println("Metrics are disabled")
+		health.SetComponentState(health.ComponentMetrics, health.StateDisabled, nil)
 	}
 
 	// Start reaping zombies from now on.
@@ -309,11 +397,31 @@ func doMain() error {
 		logTermination(err)
 		return err
 	}
+	setStartupPhase(ready.PhaseStarted)
 	// Wait for terminate signal
 	<-signalControl
 	return nil
 }
 
+// startupPhase is the current initialization phase, which is added to termination messages
+var startupPhase string
+
+// startupPhaseTime is when the current initialization phase started
+var startupPhaseTime time.Time
+
+// setStartupPhase records the current initialization phase, for use by chkmqstarted
+func setStartupPhase(phase string) {
+	if startupPhase != "" {
+		log.With("phase", startupPhase, "durationSeconds", time.Since(startupPhaseTime).Seconds()).Debugf("Completed startup phase %s", startupPhase)
+	}
+	startupPhase = phase
+	startupPhaseTime = time.Now()
+	err := ready.SetStartupPhase(phase)
+	if err != nil {
+		log.Debugf("Failed to record startup phase %s: %v", phase, err)
+	}
+}
+
 var osExit = os.Exit
 
 func main() {
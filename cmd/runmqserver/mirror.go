@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2019
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -19,32 +19,70 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
 
+// pollInterval is the time between checks for changes to files, when inotify is not being used
+const pollInterval = 500 * time.Millisecond
+
+// fileWatcher waits for changes to files
+type fileWatcher interface {
+	// wait waits until a file might have changed, or the context used to create the watcher is cancelled
+	wait()
+	close()
+}
+
+// pollWatcher is a fileWatcher which waits for a fixed interval
+type pollWatcher struct {
+	ctx context.Context
+}
+
+func (w *pollWatcher) wait() {
+	select {
+	case <-w.ctx.Done():
+	case <-time.After(pollInterval):
+	}
+}
+
+func (w *pollWatcher) close() {}
+
 // waitForFile waits until the specified file exists
-func waitForFile(ctx context.Context, path string) (os.FileInfo, error) {
-	var fi os.FileInfo
-	var err error
-	// Wait for file to exist
+func waitForFile(ctx context.Context, path string, watcher fileWatcher) (os.FileInfo, error) {
 	for {
-		select {
+		fi, err := os.Stat(path)
+		if err == nil {
+			return fi, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("mirror: unable to get info on file %v", path)
+		}
 		// Check to see if cancellation has been requested
+		select {
 		case <-ctx.Done():
-			return os.Stat(path)
+			return nil, nil
 		default:
-			fi, err = os.Stat(path)
-			if err != nil {
-				if os.IsNotExist(err) {
-					time.Sleep(500 * time.Millisecond)
-					continue
-				} else {
-					return nil, fmt.Errorf("mirror: unable to get info on file %v", path)
-				}
-			}
-			return fi, nil
+			watcher.wait()
+		}
+	}
+}
+
+// openLog opens the specified file, waiting for it to exist if necessary.  No file is returned if the
+// context is cancelled while waiting.
+func openLog(ctx context.Context, path string, watcher fileWatcher) (*os.File, error) {
+	for {
+		// #nosec G304 - no harm, we open readonly and check error.
+		f, err := os.OpenFile(path, os.O_RDONLY, 0)
+		if err == nil || !os.IsNotExist(err) {
+			return f, err
+		}
+		// The file might be part way through being rotated
+		fi, err := waitForFile(ctx, path, watcher)
+		if err != nil || fi == nil {
+			return nil, err
 		}
 	}
 }
@@ -71,15 +109,112 @@ func mirrorAvailableMessages(f *os.File, mf mirrorFunc, isQMLog bool) {
 	}
 }
 
+// mirrorRotatedLogs mirrors any rotated log files which were created after the previous file, but were rotated
+// before they could be opened.  The rotated files are ordered from newest to oldest.
+func mirrorRotatedLogs(previous, current os.FileInfo, rotated []string, mf mirrorFunc, isQMLog bool) {
+	if len(rotated) == 0 {
+		return
+	}
+	unread := []string{}
+	found := false
+	for _, path := range rotated {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if os.SameFile(fi, previous) {
+			found = true
+			break
+		}
+		if !os.SameFile(fi, current) {
+			unread = append(unread, path)
+		}
+	}
+	if !found {
+		log.Printf("Some messages might not have been mirrored from %v, because the log rotated too quickly", filepath.Base(rotated[len(rotated)-1]))
+	}
+	// Mirror the oldest file first, so that messages stay in order
+	for i := len(unread) - 1; i >= 0; i-- {
+		log.Debugf("Mirroring rotated log file %v", unread[i])
+		// #nosec G304 - no harm, we open readonly and check error.
+		f, err := os.OpenFile(unread[i], os.O_RDONLY, 0)
+		if err != nil {
+			log.Errorf("Unable to open rotated log file %v: %v", unread[i], err)
+			continue
+		}
+		mirrorAvailableMessages(f, mf, isQMLog)
+		err = f.Close()
+		if err != nil {
+			log.Errorf("Unable to close mirror file handle: %v", err)
+		}
+	}
+}
+
+// resumeFromCheckpoint returns the offset at which to resume mirroring the current file, and opens any rotated
+// files which must be mirrored first, from oldest to newest.  The offset is -1 if the checkpoint can't be used.
+func resumeFromCheckpoint(checkpoint mirrorCheckpoint, fi os.FileInfo, rotated []string) (int64, []*os.File) {
+	if checkpoint.matches(fi) {
+		if checkpoint.Offset > fi.Size() {
+			return -1, nil
+		}
+		return checkpoint.Offset, nil
+	}
+	// The log has rotated since the checkpoint was saved, so look for the file in the rotated files
+	files := []*os.File{}
+	found := false
+	for i := len(rotated) - 1; i >= 0; i-- {
+		// #nosec G304 - no harm, we open readonly and check error.
+		f, err := os.OpenFile(rotated[i], os.O_RDONLY, 0)
+		if err != nil {
+			continue
+		}
+		rfi, err := f.Stat()
+		if err == nil && !found && checkpoint.matches(rfi) && checkpoint.Offset <= rfi.Size() {
+			found = true
+			_, err = f.Seek(checkpoint.Offset, 0)
+		}
+		if err != nil || !found {
+			// #nosec G104 - the file was only read
+			f.Close()
+			continue
+		}
+		files = append(files, f)
+	}
+	if !found {
+		return -1, nil
+	}
+	return 0, files
+}
+
 // mirrorLog tails the specified file, and logs each line to stdout.
 // This is useful for usability, as the container console log can show
 // messages from the MQ error logs.
-func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart bool, mf mirrorFunc, isQMLog bool) (chan error, error) {
+// The rotated paths are the names the file is given when the log rotates, from newest to oldest, for example
+// AMQERR02.json and AMQERR03.json.  They are used to mirror files which were rotated before they could be opened.
+func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart bool, mf mirrorFunc, isQMLog bool, rotated ...string) (chan error, error) {
+	return mirrorLogWithCheckpoint(ctx, wg, path, fromStart, mf, isQMLog, nil, rotated...)
+}
+
+// mirrorLogWithCheckpoint mirrors the specified file in the same way as mirrorLog.  If a checkpointer is
+// supplied, the position reached is saved, and its policy decides where to start mirroring: either from the
+// saved position, or from the start or end of the file.
+func mirrorLogWithCheckpoint(ctx context.Context, wg *sync.WaitGroup, path string, fromStart bool, mf mirrorFunc, isQMLog bool, cp *checkpointer, rotated ...string) (chan error, error) {
 	errorChannel := make(chan error, 1)
 	var offset int64 = -1
 	var f *os.File
 	var err error
 	var fi os.FileInfo
+	var checkpoint *mirrorCheckpoint
+	// Files which rotated after the checkpoint was saved, which are mirrored first
+	var resumeFiles []*os.File
+	if cp != nil {
+		switch cp.policy {
+		case mirrorStart:
+			fromStart = true
+		case mirrorResume:
+			checkpoint = cp.load()
+		}
+	}
 	// Need to check if the file exists before returning, otherwise we have a
 	// race to see if the new file get created before we can test for it
 	fi, err = os.Stat(path)
@@ -102,34 +237,49 @@ func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart b
 		}
 		// File already exists, so start reading at the end
 		offset = fi.Size()
+		if checkpoint != nil {
+			resumeOffset, files := resumeFromCheckpoint(*checkpoint, fi, rotated)
+			if resumeOffset >= 0 {
+				log.Debugf("Resuming mirroring of %v from checkpoint in %v", path, checkpoint.Path)
+				offset = resumeOffset
+				fromStart = false
+				resumeFiles = files
+			} else {
+				log.Debugf("Unable to resume mirroring of %v from checkpoint, because the file has changed", path)
+			}
+		}
 	}
 	// Increment wait group counter, only if the goroutine gets started
 	wg.Add(1)
 	go func() {
+		// Watch the directory for changes, so that messages are mirrored as soon as they are written
+		watcher := newFileWatcher(ctx, filepath.Dir(path))
 		// Notify the wait group when this goroutine ends
 		defer func() {
+			watcher.close()
+			for _, rf := range resumeFiles {
+				// #nosec G104 - the file was only read
+				rf.Close()
+			}
+			if f != nil {
+				// #nosec G104 - the file was only read
+				f.Close()
+			}
 			log.Debugf("Finished monitoring %v", path)
 			wg.Done()
 		}()
 		if f == nil {
 			// File didn't exist, so need to wait for it
-			fi, err = waitForFile(ctx, path)
+			f, err = openLog(ctx, path, watcher)
 			if err != nil {
 				log.Error(err)
 				errorChannel <- err
 				return
 			}
-			if fi == nil {
-				return
-			}
-			log.Debugf("File exists: %v, %v", path, fi.Size())
-			// #nosec G304 - no harm, we open readonly and check error.
-			f, err = os.OpenFile(path, os.O_RDONLY, 0)
-			if err != nil {
-				log.Error(err)
-				errorChannel <- err
+			if f == nil {
 				return
 			}
+			log.Debugf("File exists: %v", path)
 		}
 
 		fi, err = f.Stat()
@@ -147,23 +297,25 @@ func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart b
 				log.Errorf("Unable to return to offset %v: %v", offset, err)
 			}
 		}
+		// Mirror any files which rotated while mirroring was stopped
+		for _, rf := range resumeFiles {
+			mirrorAvailableMessages(rf, mf, isQMLog)
+		}
 		closing := false
 		for {
 			// If there's already data there, mirror it now.
 			mirrorAvailableMessages(f, mf, isQMLog)
-			// Wait for the new log file (after rotation)
-			newFI, err := waitForFile(ctx, path)
-			if err != nil {
+			// Check for a new log file (after rotation)
+			newFI, err := os.Stat(path)
+			if err != nil && !os.IsNotExist(err) {
+				err = fmt.Errorf("mirror: unable to get info on file %v", path)
 				log.Error(err)
 				errorChannel <- err
 				return
 			}
-			if !os.SameFile(fi, newFI) {
+			if err == nil && !os.SameFile(fi, newFI) {
 				log.Debugf("Detected log rotation in file %v", path)
-				// WARNING: There is a possible race condition here.  If *another*
-				// log rotation happens before we can open the new file, then we
-				// could skip all those messages.  This could happen with a very small
-				// MQ error log size.
+				// The open file has been renamed, so mirror the rest of it before closing it
 				mirrorAvailableMessages(f, mf, isQMLog)
 				err = f.Close()
 				if err != nil {
@@ -171,17 +323,36 @@ func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart b
 				}
 				// Re-open file
 				log.Debugf("Re-opening error log file %v", path)
-				// #nosec G304 - no harm, we open readonly and check error.
-				f, err = os.OpenFile(path, os.O_RDONLY, 0)
+				f, err = openLog(ctx, path, watcher)
 				if err != nil {
 					log.Error(err)
 					errorChannel <- err
 					return
 				}
+				if f == nil {
+					return
+				}
+				newFI, err = f.Stat()
+				if err != nil {
+					log.Error(err)
+					errorChannel <- err
+					return
+				}
+				// If the log rotated more than once, mirror the files which were never opened
+				mirrorRotatedLogs(fi, newFI, rotated, mf, isQMLog)
 				fi = newFI
 				// Don't seek this time, because we know it's a new file
 				mirrorAvailableMessages(f, mf, isQMLog)
 			}
+			// The checkpoint is only saved when the position has changed, as the file is checked frequently
+			if cp != nil {
+				offset, err := f.Seek(0, io.SeekCurrent)
+				if err != nil {
+					log.Debugf("Unable to get position in file %v: %v", path, err)
+				} else if cp.changed(fi, offset) {
+					cp.save(f.Name(), fi, offset)
+				}
+			}
 			select {
 			case <-ctx.Done():
 				log.Debugf("Context cancelled for mirroring %v", path)
@@ -192,7 +363,7 @@ func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart b
 				// Set a flag, to allow one more time through the loop
 				closing = true
 			default:
-				time.Sleep(500 * time.Millisecond)
+				watcher.wait()
 			}
 		}
 	}()
@@ -0,0 +1,150 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// checkpointDir is the directory on the data volume where mirror checkpoints are saved
+const checkpointDir = "/var/mqm/mirror"
+
+// Policies for where to start mirroring a log file
+const (
+	mirrorResume = "resume"
+	mirrorEnd    = "end"
+	mirrorStart  = "start"
+)
+
+// mirrorCheckpoint is the position in a log file up to which messages have been mirrored
+type mirrorCheckpoint struct {
+	Path    string    `json:"path"`
+	Device  uint64    `json:"device"`
+	Inode   uint64    `json:"inode"`
+	Offset  int64     `json:"offset"`
+	Updated time.Time `json:"updated"`
+}
+
+// matches returns true if the checkpoint is for the specified file
+func (c mirrorCheckpoint) matches(fi os.FileInfo) bool {
+	device, inode, ok := fileIdentity(fi)
+	return ok && device == c.Device && inode == c.Inode
+}
+
+// checkpointer saves the position reached when mirroring a log file, and sets where mirroring starts
+type checkpointer struct {
+	file   string
+	policy string
+	last   mirrorCheckpoint
+}
+
+// newCheckpointer returns a checkpointer for a mirrored log, saving checkpoints in the specified directory
+func newCheckpointer(dir, name, policy string) *checkpointer {
+	return &checkpointer{file: filepath.Join(dir, name+".json"), policy: policy}
+}
+
+// load returns the saved checkpoint, or nil if there isn't one
+func (c *checkpointer) load() *mirrorCheckpoint {
+	// #nosec G304 - the file name is derived from a constant
+	buf, err := os.ReadFile(c.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Unable to read mirror checkpoint %v: %v", c.file, err)
+		}
+		return nil
+	}
+	var checkpoint mirrorCheckpoint
+	err = json.Unmarshal(buf, &checkpoint)
+	if err != nil {
+		log.Printf("Unable to parse mirror checkpoint %v: %v", c.file, err)
+		return nil
+	}
+	c.last = checkpoint
+	return &checkpoint
+}
+
+// changed returns true if the position reached in the specified file is different from the last saved checkpoint.
+// Checkpoints are only saved when the policy is to resume, so that the data volume is not written to otherwise.
+func (c *checkpointer) changed(fi os.FileInfo, offset int64) bool {
+	if c.policy != mirrorResume {
+		return false
+	}
+	device, inode, ok := fileIdentity(fi)
+	return ok && (device != c.last.Device || inode != c.last.Inode || offset != c.last.Offset)
+}
+
+// save records the position reached in the specified file
+func (c *checkpointer) save(path string, fi os.FileInfo, offset int64) {
+	device, inode, ok := fileIdentity(fi)
+	if !ok {
+		return
+	}
+	checkpoint := mirrorCheckpoint{Path: path, Device: device, Inode: inode, Offset: offset, Updated: time.Now().UTC()}
+	buf, err := json.Marshal(checkpoint)
+	if err != nil {
+		log.Debugf("Unable to encode mirror checkpoint: %v", err)
+		return
+	}
+	// #nosec G301 - write group permissions are required
+	err = os.MkdirAll(filepath.Dir(c.file), 0770)
+	if err == nil {
+		// Write to a temporary file first, so that a partially written checkpoint is never used
+		// #nosec G306 - its a read by owner/s group, and pose no harm.
+		err = os.WriteFile(c.file+".tmp", buf, 0660)
+	}
+	if err == nil {
+		err = os.Rename(c.file+".tmp", c.file)
+	}
+	if err != nil {
+		log.Debugf("Unable to save mirror checkpoint %v: %v", c.file, err)
+		return
+	}
+	c.last = checkpoint
+}
+
+// getMirrorPolicy returns the policy for where to start mirroring a log source, set using MQ_LOGGING_CONSOLE_POSITION.
+// This is either a single policy for all sources, or a comma-separated list of source=policy pairs, for example
+// "qmgr=resume,web=end".  The default policy is to start at the end, and resuming from a checkpoint must be
+// selected explicitly.
+func getMirrorPolicy(source string) (string, error) {
+	value := strings.TrimSpace(os.Getenv("MQ_LOGGING_CONSOLE_POSITION"))
+	policy := mirrorEnd
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, entryPolicy, found := strings.Cut(entry, "=")
+		if !found {
+			entryPolicy = name
+		} else if strings.TrimSpace(name) != source {
+			continue
+		}
+		entryPolicy = strings.ToLower(strings.TrimSpace(entryPolicy))
+		switch entryPolicy {
+		case mirrorResume, mirrorEnd, mirrorStart:
+			policy = entryPolicy
+		default:
+			return "", fmt.Errorf("Invalid value for MQ_LOGGING_CONSOLE_POSITION: %v.  Allowed policies are 'resume', 'end' and 'start'", value)
+		}
+	}
+	return policy, nil
+}
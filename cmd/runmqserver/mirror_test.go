@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -192,3 +193,250 @@ func TestMirrorLogCancelWhileWaiting(t *testing.T) {
 	wg.Wait()
 	// No need to assert anything.  If it didn't work, the code would have hung (TODO: not ideal)
 }
+
+// rotateErrorLog rotates a set of error logs in the same way as MQ
+func rotateErrorLog(t *testing.T, dir string) {
+	os.Remove(filepath.Join(dir, "AMQERR03.json"))
+	os.Rename(filepath.Join(dir, "AMQERR02.json"), filepath.Join(dir, "AMQERR03.json"))
+	err := os.Rename(filepath.Join(dir, "AMQERR01.json"), filepath.Join(dir, "AMQERR02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMirrorLogWithMultipleRotations tests that no messages are lost if the log rotates again
+// before the new file is opened
+func TestMirrorLogWithMultipleRotations(t *testing.T) {
+	// Repeat the test multiple times, to help identify timing problems
+	for i := 0; i < 5; i++ {
+		t.Run(t.Name()+strconv.Itoa(i), func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "AMQERR01.json")
+			os.WriteFile(path, []byte{}, 0600)
+			var mutex sync.Mutex
+			messages := []string{}
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			_, err := mirrorLog(ctx, &wg, path, true, func(msg string, isQMLog bool) bool {
+				mutex.Lock()
+				defer mutex.Unlock()
+				messages = append(messages, msg)
+				return true
+			}, false, errorLogRotation(dir)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, batch := range [][]string{{"A", "B"}, {"C"}, {"D", "E"}} {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, message := range batch {
+					fmt.Fprintf(f, "{\"message\"=\"%v\"}\n", message)
+				}
+				f.Close()
+				rotateErrorLog(t, dir)
+			}
+			os.WriteFile(path, []byte("{\"message\"=\"F\"}\n"), 0600)
+
+			// Shut the mirroring down
+			cancel()
+			wg.Wait()
+
+			expected := []string{"A", "B", "C", "D", "E", "F"}
+			if len(messages) != len(expected) {
+				t.Fatalf("Expected %v log entries; got %v", len(expected), messages)
+			}
+			for i, message := range expected {
+				if messages[i] != fmt.Sprintf("{\"message\"=\"%v\"}", message) {
+					t.Errorf("Expected message %v to be %v; got %v", i, message, messages[i])
+				}
+			}
+		})
+	}
+}
+
+// runMirrorWithCheckpoint mirrors a log until the context is cancelled, returning the messages which were mirrored
+func runMirrorWithCheckpoint(t *testing.T, dir string, cp *checkpointer, whileRunning func()) []string {
+	t.Helper()
+	var mutex sync.Mutex
+	messages := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	_, err := mirrorLogWithCheckpoint(ctx, &wg, filepath.Join(dir, "AMQERR01.json"), false, func(msg string, isQMLog bool) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		messages = append(messages, msg)
+		return true
+	}, false, cp, errorLogRotation(dir)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whileRunning()
+	cancel()
+	wg.Wait()
+	return messages
+}
+
+func appendMessages(t *testing.T, path string, messages ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, message := range messages {
+		fmt.Fprintln(f, message)
+	}
+}
+
+func TestMirrorLogResumeFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AMQERR01.json")
+	appendMessages(t, path, "A")
+	cp := newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume)
+
+	messages := runMirrorWithCheckpoint(t, dir, cp, func() {
+		appendMessages(t, path, "B", "C")
+		time.Sleep(200 * time.Millisecond)
+	})
+	if strings.Join(messages, ",") != "B,C" {
+		t.Fatalf("Expected messages B,C; got %v", messages)
+	}
+
+	// Write more messages while mirroring is stopped
+	appendMessages(t, path, "D", "E")
+
+	cp = newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume)
+	messages = runMirrorWithCheckpoint(t, dir, cp, func() {
+		appendMessages(t, path, "F")
+		time.Sleep(200 * time.Millisecond)
+	})
+	if strings.Join(messages, ",") != "D,E,F" {
+		t.Fatalf("Expected messages D,E,F after resuming; got %v", messages)
+	}
+}
+
+func TestMirrorLogResumeAfterRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AMQERR01.json")
+	appendMessages(t, path, "A")
+	cp := newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume)
+
+	runMirrorWithCheckpoint(t, dir, cp, func() {
+		appendMessages(t, path, "B")
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	// Rotate the log twice while mirroring is stopped
+	appendMessages(t, path, "C")
+	rotateErrorLog(t, dir)
+	appendMessages(t, path, "D")
+	rotateErrorLog(t, dir)
+	appendMessages(t, path, "E")
+
+	cp = newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume)
+	messages := runMirrorWithCheckpoint(t, dir, cp, func() {
+		time.Sleep(200 * time.Millisecond)
+	})
+	if strings.Join(messages, ",") != "C,D,E" {
+		t.Fatalf("Expected messages C,D,E after resuming; got %v", messages)
+	}
+}
+
+func TestMirrorLogEndPolicyIgnoresCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AMQERR01.json")
+	appendMessages(t, path, "A")
+
+	runMirrorWithCheckpoint(t, dir, newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume), func() {
+		time.Sleep(200 * time.Millisecond)
+	})
+	appendMessages(t, path, "B")
+
+	messages := runMirrorWithCheckpoint(t, dir, newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorEnd), func() {
+		appendMessages(t, path, "C")
+		time.Sleep(200 * time.Millisecond)
+	})
+	if strings.Join(messages, ",") != "C" {
+		t.Fatalf("Expected message C; got %v", messages)
+	}
+}
+
+var mirrorPolicyTests = []struct {
+	value    string
+	source   string
+	expected string
+	valid    bool
+}{
+	{"", "qmgr", mirrorEnd, true},
+	{"start", "qmgr", mirrorStart, true},
+	{"END", "web", mirrorEnd, true},
+	{"qmgr=start,web=end", "qmgr", mirrorStart, true},
+	{"qmgr=start,web=end", "web", mirrorEnd, true},
+	{"web=resume", "qmgr", mirrorEnd, true},
+	{"web=resume", "web", mirrorResume, true},
+	{"end,web=start", "web", mirrorStart, true},
+	{"qmgr=latest", "qmgr", "", false},
+}
+
+func TestGetMirrorPolicy(t *testing.T) {
+	for _, table := range mirrorPolicyTests {
+		t.Run(table.value+"/"+table.source, func(t *testing.T) {
+			t.Setenv("MQ_LOGGING_CONSOLE_POSITION", table.value)
+			policy, err := getMirrorPolicy(table.source)
+			if table.valid && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !table.valid && err == nil {
+				t.Fatalf("Expected an error for %v", table.value)
+			}
+			if policy != table.expected {
+				t.Errorf("Expected policy %v; got %v", table.expected, policy)
+			}
+		})
+	}
+}
+
+func TestCheckpointOnlySavedForResume(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AMQERR01.json")
+	appendMessages(t, path, "A")
+
+	for _, policy := range []string{mirrorEnd, mirrorStart} {
+		runMirrorWithCheckpoint(t, dir, newCheckpointer(filepath.Join(dir, "mirror"), policy, policy), func() {
+			appendMessages(t, path, "B")
+			time.Sleep(200 * time.Millisecond)
+		})
+		_, err := os.Stat(filepath.Join(dir, "mirror", policy+".json"))
+		if !os.IsNotExist(err) {
+			t.Errorf("Expected no checkpoint to be saved with policy %v; got error %v", policy, err)
+		}
+	}
+}
+
+func TestCheckpointChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AMQERR01.json")
+	appendMessages(t, path, "A")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cp := newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume)
+	if !cp.changed(fi, 2) {
+		t.Fatal("Expected a new position to be a change")
+	}
+	cp.save(path, fi, 2)
+	if cp.changed(fi, 2) {
+		t.Error("Expected the saved position not to be a change")
+	}
+	if !cp.changed(fi, 4) {
+		t.Error("Expected a different offset to be a change")
+	}
+	loaded := newCheckpointer(filepath.Join(dir, "mirror"), "test", mirrorResume).load()
+	if loaded == nil || loaded.Offset != 2 || !loaded.matches(fi) {
+		t.Errorf("Expected saved checkpoint at offset 2; got %+v", loaded)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyTimeout is the longest time to wait for an event, so that files are still checked if an event is missed
+const inotifyTimeout = 10 * time.Second
+
+const inotifyMask = unix.IN_MODIFY | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_CLOSE_WRITE
+
+// inotifyWatcher waits for changes to the files in a directory using inotify
+type inotifyWatcher struct {
+	dir      string
+	fd       int
+	wake     [2]int
+	watching bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// newFileWatcher returns a watcher for changes to the files in a directory, which uses inotify if it is
+// available, and otherwise polls.  The watcher returns immediately once the context is cancelled.
+func newFileWatcher(ctx context.Context, dir string) fileWatcher {
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK | unix.IN_CLOEXEC)
+	if err != nil {
+		log.Debugf("Unable to use inotify for %v, so polling instead: %v", dir, err)
+		return &pollWatcher{ctx: ctx}
+	}
+	w := &inotifyWatcher{dir: dir, fd: fd, stop: make(chan struct{}), stopped: make(chan struct{})}
+	// A pipe is used to wake the watcher when the context is cancelled
+	err = unix.Pipe2(w.wake[:], unix.O_NONBLOCK|unix.O_CLOEXEC)
+	if err != nil {
+		log.Debugf("Unable to use inotify for %v, so polling instead: %v", dir, err)
+		// #nosec G104 - the file descriptor is not used again
+		unix.Close(fd)
+		return &pollWatcher{ctx: ctx}
+	}
+	go func() {
+		defer close(w.stopped)
+		select {
+		case <-ctx.Done():
+			// #nosec G104 - the pipe is empty, so the write can't block or fail
+			unix.Write(w.wake[1], []byte{0})
+		case <-w.stop:
+		}
+	}()
+	return w
+}
+
+// wait waits until a file in the directory might have changed
+func (w *inotifyWatcher) wait() {
+	timeout := inotifyTimeout
+	if !w.watching {
+		// The directory might not exist yet, in which case poll until it does
+		_, err := unix.InotifyAddWatch(w.fd, w.dir, inotifyMask)
+		if err == nil {
+			w.watching = true
+		} else {
+			timeout = pollInterval
+		}
+	}
+	fds := []unix.PollFd{
+		{Fd: int32(w.fd), Events: unix.POLLIN},
+		{Fd: int32(w.wake[0]), Events: unix.POLLIN},
+	}
+	// #nosec G104 - an interrupted wait is the same as a change, as the caller checks the files again
+	unix.Poll(fds, int(timeout/time.Millisecond))
+	w.readEvents()
+}
+
+// readEvents discards any pending events, as the caller checks the files for changes, but notes if the
+// watch has been removed, for example because the directory was deleted
+func (w *inotifyWatcher) readEvents() {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.PathMax))
+	for {
+		n, err := unix.Read(w.fd, buf)
+		if err != nil || n < unix.SizeofInotifyEvent {
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			// #nosec G103 - the kernel writes complete inotify_event structures to the buffer
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			if event.Mask&unix.IN_IGNORED != 0 {
+				w.watching = false
+			}
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+		}
+	}
+}
+
+// close releases the resources used by the watcher
+func (w *inotifyWatcher) close() {
+	close(w.stop)
+	<-w.stopped
+	// #nosec G104 - the file descriptors are not used again
+	unix.Close(w.fd)
+	// #nosec G104
+	unix.Close(w.wake[0])
+	// #nosec G104
+	unix.Close(w.wake[1])
+}
+
+// fileIdentity returns the device and inode of a file, which are used to match mirror checkpoints to files
+func fileIdentity(fi os.FileInfo) (uint64, uint64, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	// #nosec G115 - device numbers are not negative
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+// +build !linux
+
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"os"
+)
+
+// newFileWatcher returns a watcher which polls for changes, as inotify is only available on Linux
+func newFileWatcher(ctx context.Context, dir string) fileWatcher {
+	return &pollWatcher{ctx: ctx}
+}
+
+// fileIdentity returns false, as the device and inode of a file are only used on Linux, so mirror checkpoints are
+// not saved on other platforms
+func fileIdentity(fi os.FileInfo) (uint64, uint64, bool) {
+	return 0, 0, false
+}
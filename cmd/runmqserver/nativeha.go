@@ -0,0 +1,49 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+)
+
+const nativeHAStatusInterval = 10 * time.Second
+
+// monitorNativeHAStatus saves the native HA status of the queue manager at regular intervals, so that it
+// can be used by the metrics and probes, until the context is cancelled
+func monitorNativeHAStatus(ctx context.Context, name string) {
+	lastError := ""
+	for {
+		status, err := ready.GetNativeHAStatus(ctx, name)
+		if err == nil {
+			err = ready.WriteNativeHAStatus(status)
+		}
+		// Only log when the error changes, to avoid filling the log while the queue manager is unavailable
+		if err != nil && ctx.Err() == nil && err.Error() != lastError {
+			log.Printf("Failed to update native HA status: %v", err)
+			lastError = err.Error()
+		} else if err == nil {
+			lastError = ""
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(nativeHAStatusInterval):
+		}
+	}
+}
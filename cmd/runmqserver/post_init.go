@@ -16,14 +16,17 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/ibm-messaging/mq-container/internal/fips"
+	"github.com/ibm-messaging/mq-container/internal/health"
 	"github.com/ibm-messaging/mq-container/internal/tls"
 )
 
-// postInit is run after /var/mqm is set up
-func postInit(name, keyLabel string, p12Truststore tls.KeyStoreData) error {
+// postInit is run after /var/mqm is set up, and returns the name of the web server keystore if the web server is enabled
+func postInit(name, keyLabel string, p12Truststore tls.KeyStoreData) (string, error) {
+	webKeystore := ""
 	enableWebServer := os.Getenv("MQ_ENABLE_EMBEDDED_WEB_SERVER")
 	if enableWebServer == "true" || enableWebServer == "1" {
 
@@ -31,14 +34,15 @@ func postInit(name, keyLabel string, p12Truststore tls.KeyStoreData) error {
 		if fips.IsFIPSEnabled() {
 			err := configureFIPSWebServer(p12Truststore)
 			if err != nil {
-				return err
+				return "", err
 			}
 		}
 
 		// Configure the web server (if enabled)
-		webKeystore, err := configureWebServer(keyLabel, p12Truststore)
+		var err error
+		webKeystore, err = configureWebServer(keyLabel, p12Truststore)
 		if err != nil {
-			return err
+			return "", err
 		}
 		// If trust-store is empty, set reference to point to the keystore
 		webTruststoreRef := "MQWebTrustStore"
@@ -47,13 +51,17 @@ func postInit(name, keyLabel string, p12Truststore tls.KeyStoreData) error {
 		}
 
 		// Start the web server, in the background (if installed)
-		// WARNING: No error handling or health checking available for the web server
+		health.SetComponentState(health.ComponentWebServer, health.StateStarting, nil)
 		go func() {
 			err = startWebServer(webKeystore, p12Truststore.Password, webTruststoreRef)
 			if err != nil {
 				log.Printf("Error starting web server: %v", err)
+				health.SetComponentState(health.ComponentWebServer, health.StateFailed, err)
+				health.SetLastError(fmt.Sprintf("Error starting web server: %v", err))
 			}
 		}()
+	} else {
+		health.SetComponentState(health.ComponentWebServer, health.StateDisabled, nil)
 	}
-	return nil
+	return webKeystore, nil
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/ibm-messaging/mq-container/internal/command"
 	containerruntime "github.com/ibm-messaging/mq-container/internal/containerruntime"
 	"github.com/ibm-messaging/mq-container/internal/mqscredact"
+	"github.com/ibm-messaging/mq-container/internal/mqtemplate"
 	"github.com/ibm-messaging/mq-container/internal/mqversion"
 	"github.com/ibm-messaging/mq-container/internal/ready"
 )
@@ -87,7 +88,11 @@ func createQueueManager(name string, devMode bool) (bool, error) {
 	_, err = os.Stat(filepath.Join(dataDir, "qm.ini"))
 	if err != nil {
 		// If 'qm.ini' is not found - run 'crtmqm' to create a new queue manager
-		args := getCreateQueueManagerArgs(mounts, name, devMode)
+		listeners, err := ready.GetListeners()
+		if err != nil {
+			return false, err
+		}
+		args := getCreateQueueManagerArgs(mounts, name, devMode, listeners[0].Port)
 		out, rc, err := command.Run("crtmqm", args...)
 		if err != nil {
 			log.Printf("Error %v creating queue manager: %v", rc, string(out))
@@ -240,7 +245,7 @@ func getQueueManagerDataDir(mounts map[string]string, name string) string {
 	return dataDir
 }
 
-func getCreateQueueManagerArgs(mounts map[string]string, name string, devMode bool) []string {
+func getCreateQueueManagerArgs(mounts map[string]string, name string, devMode bool, listenerPort int) []string {
 
 	mqversionBase := "9.2.1.0"
 
@@ -256,7 +261,7 @@ func getCreateQueueManagerArgs(mounts map[string]string, name string, devMode bo
 	}
 
 	//build args
-	args := []string{"-ii", "/etc/mqm/", "-ic", "/etc/mqm/", "-q", "-p", "1414"}
+	args := []string{"-ii", "/etc/mqm/", "-ic", "/etc/mqm/", "-q", "-p", strconv.Itoa(listenerPort)}
 
 	if os.Getenv("MQ_NATIVE_HA") == "true" {
 		args = append(args, "-lr", os.Getenv("HOSTNAME"))
@@ -282,6 +287,36 @@ func getCreateQueueManagerArgs(mounts map[string]string, name string, devMode bo
 	return args
 }
 
+// configureListeners generates MQSC to define a listener for each port set in MQ_LISTENER_PORTS, so that
+// changes to the ports are applied each time the queue manager starts
+func configureListeners() error {
+	const mqsc string = "/etc/mqm/05-listeners.mqsc"
+	const mqscTemplate string = mqsc + ".tpl"
+
+	if os.Getenv("MQ_LISTENER_PORTS") == "" {
+		err := os.Remove(mqsc)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove file %s: %v", mqsc, err)
+		}
+		return nil
+	}
+
+	listeners, err := ready.GetListeners()
+	if err != nil {
+		return err
+	}
+	type listenerDefinition struct {
+		Name string
+		Port int
+	}
+	definitions := []listenerDefinition{}
+	for i, listener := range listeners {
+		// The first listener is the one created by crtmqm
+		definitions = append(definitions, listenerDefinition{Name: fmt.Sprintf("SYSTEM.LISTENER.TCP.%d", i+1), Port: listener.Port})
+	}
+	return mqtemplate.ProcessTemplateFile(mqscTemplate, mqsc, map[string]interface{}{"Listeners": definitions}, log)
+}
+
 func getCreateStandbyQueueManagerArgs(name string) []string {
 	args := []string{"-s", "QueueManager"}
 	args = append(args, "-v", fmt.Sprintf("Name=%v", name))
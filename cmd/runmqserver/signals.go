@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2017, 2022
+© Copyright IBM Corporation 2017, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -20,6 +20,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/ibm-messaging/mq-container/internal/health"
 	"github.com/ibm-messaging/mq-container/internal/metrics"
 	"golang.org/x/sys/unix"
 )
@@ -35,7 +36,9 @@ func signalHandler(qmgr string) chan int {
 	// the buffer, and preventing other signals.
 	stopSignals := make(chan os.Signal, 1)
 	reapSignals := make(chan os.Signal, 1)
+	logLevelSignals := make(chan os.Signal, 1)
 	signal.Notify(stopSignals, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(logLevelSignals, syscall.SIGUSR1, syscall.SIGUSR2)
 	go func() {
 		for {
 			select {
@@ -43,17 +46,26 @@ func signalHandler(qmgr string) chan int {
 				log.Printf("Signal received: %v", sig)
 				signal.Stop(reapSignals)
 				signal.Stop(stopSignals)
+				signal.Stop(logLevelSignals)
 				metrics.StopMetricsGathering(log)
 				// #nosec G104
 				stopQueueManager(qmgr)
 				// One final reap
 				reapZombies()
+				health.Stop(log)
 				close(control)
 				// End the goroutine
 				return
 			case <-reapSignals:
 				log.Debug("Received SIGCHLD signal")
 				reapZombies()
+			case sig := <-logLevelSignals:
+				// SIGUSR1 turns on debug logging, and SIGUSR2 turns it off again
+				if sig == syscall.SIGUSR1 {
+					setDebugLogLevels()
+				} else {
+					restoreLogLevels()
+				}
 			case job := <-control:
 				switch {
 				case job == startReaping:
@@ -0,0 +1,237 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/ibm-messaging/mq-container/internal/tls"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// getTLSReloadInterval returns how often the keys and trust certificates are checked for changes, or zero if
+// they should not be reloaded.  Reloading is off unless MQ_TLS_RELOAD_INTERVAL is set.
+func getTLSReloadInterval() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("MQ_TLS_RELOAD_INTERVAL"))
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("Invalid value for MQ_TLS_RELOAD_INTERVAL: %v.  The value must be a number of seconds, or 0 to disable reloading", value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// tlsReloader rebuilds the keystores when the keys or trust certificates change
+type tlsReloader struct {
+	name     string
+	keyLabel string
+	password string
+	devMode  bool
+	log      *logger.Logger
+	// notReloaded describes the keystores which are not rebuilt from the keys and trust certificates
+	notReloaded []string
+	// checksum is the checksum of the keys and trust certificates which were last loaded
+	checksum string
+	// getChecksum returns the checksum of the current keys and trust certificates
+	getChecksum func() (string, error)
+	// apply rebuilds the keystores and applies them to the queue manager
+	apply func(ctx context.Context)
+}
+
+// newTLSReloader returns a tlsReloader for the queue manager.  The web server keystore is only rebuilt if it is
+// the PKCS#12 keystore for the certificate label, rather than one generated for MQ_GENERATE_CERTIFICATE_HOSTNAME.
+func newTLSReloader(name string, keyLabel string, password string, devMode bool, webKeystore string) *tlsReloader {
+	r := &tlsReloader{
+		name:        name,
+		keyLabel:    keyLabel,
+		password:    password,
+		devMode:     devMode,
+		log:         log.With("component", "tls"),
+		notReloaded: []string{},
+		getChecksum: tls.DefaultTLSChecksum,
+	}
+	r.apply = r.reload
+	if webKeystore != "" && webKeystore != keyLabel+".p12" {
+		r.notReloaded = append(r.notReloaded, fmt.Sprintf("web server keystore %s", webKeystore))
+	}
+	if os.Getenv("MQ_NATIVE_HA") == "true" && os.Getenv("MQ_NATIVE_HA_TLS") == "true" {
+		r.notReloaded = append(r.notReloaded, "native HA keystore, which is built from /etc/mqm/ha/pki/keys")
+	}
+	return r
+}
+
+// watchTLS checks the keys and trust certificates for changes at the specified interval, until the context is
+// cancelled
+func watchTLS(ctx context.Context, name string, keyLabel string, password string, devMode bool, webKeystore string, interval time.Duration) {
+	newTLSReloader(name, keyLabel, password, devMode, webKeystore).watch(ctx, interval)
+}
+
+// watch checks the keys and trust certificates for changes at the specified interval, until the context is
+// cancelled.  A change is applied once the files have stayed the same for a whole interval, so that a set of
+// keys is not loaded while it is being updated.
+func (r *tlsReloader) watch(ctx context.Context, interval time.Duration) {
+	var err error
+	r.checksum, err = r.getChecksum()
+	if err != nil {
+		r.log.Errorf("Unable to check TLS certificates for changes: %v", err)
+		return
+	}
+	r.log.Debugf("Checking TLS certificates for changes every %v", interval)
+	for _, store := range r.notReloaded {
+		r.log.Printf("The %s is not reloaded when the TLS certificates change, and is only updated when the container is restarted", store)
+	}
+	pending := r.checksum
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		checksum, err := r.getChecksum()
+		if err != nil {
+			r.log.Debugf("Unable to check TLS certificates for changes: %v", err)
+			continue
+		}
+		if checksum != r.checksum && checksum == pending {
+			r.apply(ctx)
+			// A failed reload is not tried again until the files change
+			r.checksum = checksum
+		}
+		pending = checksum
+	}
+}
+
+// reload rebuilds the keystores, and refreshes the queue manager's cached copy of the CMS keystore.  The web
+// server checks its keystore files for changes itself.
+func (r *tlsReloader) reload(ctx context.Context) {
+	r.log.Println("TLS certificates have changed, so reloading the keystores")
+	keyLabel, cmsKeystore, _, err := tls.ReloadDefaultTLSKeystores(r.password, r.log)
+	if err != nil {
+		r.log.Errorf("Failed to reload TLS certificates, so the previous certificates are still used: %v", err)
+		return
+	}
+	err = tls.ReloadMetricsTLS(keyLabel)
+	if err != nil {
+		r.log.Errorf("Failed to reload TLS certificates for the metrics server, so the previous certificates are still used: %v", err)
+	}
+	// Update the MQSC used when the queue manager is next started
+	err = tls.ConfigureTLS(keyLabel, cmsKeystore, r.devMode, r.log)
+	if err != nil {
+		r.log.Errorf("Failed to update TLS configuration: %v", err)
+	}
+
+	mqsc := []string{}
+	if keyLabel != r.keyLabel {
+		sslKeyRing := ""
+		if cmsKeystore.Keystore != nil && keyLabel != "" {
+			sslKeyRing = strings.TrimSuffix(cmsKeystore.Keystore.Filename, ".kdb")
+		}
+		mqsc = append(mqsc, fmt.Sprintf("ALTER QMGR SSLKEYR('%s') CERTLABL('%s')", sslKeyRing, keyLabel))
+		r.log.Printf("Certificate label changed from '%s' to '%s'.  The web server uses the previous certificate until the container is restarted", r.keyLabel, keyLabel)
+		r.keyLabel = keyLabel
+	}
+	channelLabels, err := tls.GetChannelLabels(cmsKeystore)
+	if err != nil {
+		r.log.Errorf("Failed to set certificate labels for channels: %v", err)
+	}
+	for _, channel := range channelLabels {
+		mqsc = append(mqsc, fmt.Sprintf("ALTER CHANNEL('%s') CHLTYPE(%s) CERTLABL('%s')", channel.Channel, channel.Type, channel.Label))
+	}
+	mqsc = append(mqsc, "REFRESH SECURITY TYPE(SSL)")
+
+	status, err := ready.Status(ctx, r.name)
+	if err != nil || !status.ActiveQM() {
+		r.log.Println("Queue manager is not active, so it uses the new TLS certificates when it next starts")
+	} else {
+		failed, err := runMQSC(r.name, mqsc)
+		if err != nil {
+			r.log.Errorf("Failed to refresh TLS certificates for the queue manager: %v", err)
+			return
+		}
+		refreshed := true
+		for _, command := range failed {
+			if strings.HasPrefix(command, "REFRESH SECURITY") {
+				refreshed = false
+				continue
+			}
+			// The other commands only change the certificate labels, so the refresh still applies the new certificates
+			r.log.Warnf("Failed to run MQSC command while reloading TLS certificates: %s", command)
+		}
+		if !refreshed {
+			r.log.Errorf("Failed to refresh TLS certificates for the queue manager, so it uses the new TLS certificates when it next starts")
+			return
+		}
+	}
+
+	fingerprints, err := tls.DefaultCertificateFingerprints()
+	if err != nil {
+		r.log.Debugf("Unable to get certificate fingerprints: %v", err)
+	}
+	r.log.With("keyLabel", keyLabel).Printf("Reloaded TLS certificates: %s", strings.Join(fingerprints, ", "))
+	for _, store := range r.notReloaded {
+		r.log.Printf("The %s was not reloaded, and uses the previous certificates until the container is restarted", store)
+	}
+	checkCertificateExpiry()
+}
+
+// mqscCommandLine matches a command echoed in the output of runmqsc, for example "     1 : REFRESH SECURITY"
+var mqscCommandLine = regexp.MustCompile(`^\s*\d+\s*:\s*(.*)$`)
+
+// mqscErrorMessage matches an error message in the output of runmqsc, for example "AMQ8147E: IBM MQ object ..."
+var mqscErrorMessage = regexp.MustCompile(`^AMQ\d{4}E`)
+
+// runMQSC runs MQSC commands against the queue manager, and returns the commands which failed.  An error is only
+// returned if runmqsc did not process the commands, rather than if some of them failed.
+func runMQSC(name string, commands []string) ([]string, error) {
+	// #nosec G204 - command is fixed, and the queue manager name is validated when the container starts
+	cmd := exec.Command("runmqsc", name)
+	cmd.Stdin = strings.NewReader(strings.Join(commands, "\n") + "\n")
+	out, err := cmd.CombinedOutput()
+	// runmqsc exits with 10 if some of the commands failed
+	if err != nil && cmd.ProcessState.ExitCode() != 10 {
+		return nil, fmt.Errorf("%v: %v", err, formatMQSCOutput(string(out)))
+	}
+	log.Debugf("Output from runmqsc:\n\t%s", formatMQSCOutput(string(out)))
+	return failedMQSCCommands(string(out)), nil
+}
+
+// failedMQSCCommands returns the commands in the output of runmqsc which were followed by an error message
+func failedMQSCCommands(out string) []string {
+	failed := []string{}
+	command := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := mqscCommandLine.FindStringSubmatch(line); match != nil {
+			command = strings.TrimSpace(match[1])
+			continue
+		}
+		if command != "" && mqscErrorMessage.MatchString(line) {
+			failed = append(failed, command)
+			command = ""
+		}
+	}
+	return failed
+}
@@ -0,0 +1,141 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var tlsReloadIntervalTests = []struct {
+	value    string
+	expected time.Duration
+	valid    bool
+}{
+	{"", 0, true},
+	{"0", 0, true},
+	{" 60 ", 60 * time.Second, true},
+	{"-1", 0, false},
+	{"30s", 0, false},
+}
+
+func TestGetTLSReloadInterval(t *testing.T) {
+	for _, table := range tlsReloadIntervalTests {
+		t.Run(table.value, func(t *testing.T) {
+			t.Setenv("MQ_TLS_RELOAD_INTERVAL", table.value)
+			interval, err := getTLSReloadInterval()
+			if table.valid && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !table.valid && err == nil {
+				t.Fatalf("Expected an error; got %v", interval)
+			}
+			if interval != table.expected {
+				t.Errorf("Expected %v; got %v", table.expected, interval)
+			}
+		})
+	}
+}
+
+var tlsWatchTests = []struct {
+	name      string
+	checksums []string
+	// expected are the indexes of the checksums after which the keystores are expected to be reloaded, where an
+	// empty checksum is an error
+	expected []int
+}{
+	{"Unchanged", []string{"a", "a", "a"}, []int{}},
+	{"ChangedOnce", []string{"a", "b", "b", "b"}, []int{2}},
+	{"StillChanging", []string{"a", "b", "c", "d"}, []int{}},
+	{"ChangedThenStable", []string{"a", "b", "c", "c", "c"}, []int{3}},
+	{"ChangedBack", []string{"a", "b", "a", "a"}, []int{}},
+	{"ChangedTwice", []string{"a", "b", "b", "c", "c"}, []int{2, 4}},
+	{"Error", []string{"a", "b", "", "b"}, []int{3}},
+}
+
+func TestTLSReloaderWatch(t *testing.T) {
+	for _, table := range tlsWatchTests {
+		t.Run(table.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			// check is the index of the checksum returned by the latest check
+			check := -1
+			reloads := []int{}
+			r := newTLSReloader("QM1", "default", "password", false, "default.p12")
+			r.getChecksum = func() (string, error) {
+				check++
+				if check == len(table.checksums)-1 {
+					cancel()
+				}
+				checksum := table.checksums[check]
+				if checksum == "" {
+					return "", errors.New("file removed")
+				}
+				return checksum, nil
+			}
+			r.apply = func(ctx context.Context) {
+				reloads = append(reloads, check)
+			}
+			r.watch(ctx, time.Millisecond)
+			if len(reloads) != len(table.expected) {
+				t.Fatalf("Expected reloads after checks %v; got %v", table.expected, reloads)
+			}
+			for i := range reloads {
+				if reloads[i] != table.expected[i] {
+					t.Errorf("Expected reloads after checks %v; got %v", table.expected, reloads)
+				}
+			}
+		})
+	}
+}
+
+func TestTLSReloaderNotReloaded(t *testing.T) {
+	t.Setenv("MQ_NATIVE_HA", "true")
+	t.Setenv("MQ_NATIVE_HA_TLS", "true")
+	r := newTLSReloader("QM1", "default", "password", false, "default.p12")
+	if len(r.notReloaded) != 1 {
+		t.Errorf("Expected only the native HA keystore not to be reloaded; got %v", r.notReloaded)
+	}
+	r = newTLSReloader("QM1", "", "password", false, "default.p12")
+	if len(r.notReloaded) != 2 {
+		t.Errorf("Expected the generated web server keystore and the native HA keystore not to be reloaded; got %v", r.notReloaded)
+	}
+}
+
+func TestFailedMQSCCommands(t *testing.T) {
+	out := `5724-H72 (C) Copyright IBM Corp. 1994, 2023.
+Starting MQSC for queue manager QM1.
+
+
+     1 : ALTER CHANNEL('APP.SVRCONN') CHLTYPE(SVRCONN) CERTLABL('mykey')
+AMQ8016I: IBM MQ channel changed.
+     2 : ALTER CHANNEL('MISSING.SVRCONN') CHLTYPE(SVRCONN) CERTLABL('mykey')
+AMQ8147E: IBM MQ object MISSING.SVRCONN not found.
+     3 : REFRESH SECURITY TYPE(SSL)
+AMQ8560I: IBM MQ security cache refreshed.
+3 MQSC commands read.
+No commands have a syntax error.
+One valid MQSC command could not be processed.
+`
+	expected := []string{"ALTER CHANNEL('MISSING.SVRCONN') CHLTYPE(SVRCONN) CERTLABL('mykey')"}
+	failed := failedMQSCCommands(out)
+	if !reflect.DeepEqual(failed, expected) {
+		t.Errorf("Expected %q; got %q", expected, failed)
+	}
+}
@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2022
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"github.com/ibm-messaging/mq-container/internal/copy"
+	"github.com/ibm-messaging/mq-container/internal/health"
 	"github.com/ibm-messaging/mq-container/internal/mqtemplate"
 	"github.com/ibm-messaging/mq-container/internal/tls"
 )
@@ -31,6 +32,7 @@ func startWebServer(webKeystore, webkeystorePW, webTruststoreRef string) error {
 	_, err := os.Stat("/opt/mqm/bin/strmqweb")
 	if err != nil && os.IsNotExist(err) {
 		log.Debug("Skipping web server, because it's not installed")
+		health.SetComponentState(health.ComponentWebServer, health.StateDisabled, nil)
 		return nil
 	}
 	log.Println("Starting web server")
@@ -58,6 +60,7 @@ func startWebServer(webKeystore, webkeystorePW, webTruststoreRef string) error {
 		return err
 	}
 	log.Println("Started web server")
+	health.SetComponentState(health.ComponentWebServer, health.StateRunning, nil)
 	return nil
 }
 
@@ -1,4 +1,4 @@
-* © Copyright IBM Corporation 2019, 2022
+* © Copyright IBM Corporation 2019, 2023
 *
 *
 * Licensed under the Apache License, Version 2.0 (the "License");
@@ -17,4 +17,9 @@
 ALTER QMGR SSLKEYR('{{ .SSLKeyR }}')
 ALTER QMGR CERTLABL('{{ .CertificateLabel }}')
 ALTER QMGR SSLFIPS({{ .SSLFips }})
+
+* Set the certificate label for channels which use a different set of keys
+{{- range .ChannelLabels }}
+ALTER CHANNEL('{{ .Channel }}') CHLTYPE({{ .Type }}) CERTLABL('{{ .Label }}')
+{{- end }}
 REFRESH SECURITY(*) TYPE(SSL)
@@ -1,7 +1,7 @@
 // +build linux
 
 /*
-© Copyright IBM Corporation 2017, 2019
+© Copyright IBM Corporation 2017, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -113,3 +113,26 @@ func GetFilesystem(path string) (string, error) {
 	}
 	return t, nil
 }
+
+// FilesystemUsage holds the size and usage of a filesystem, in bytes
+type FilesystemUsage struct {
+	Size      uint64
+	Free      uint64
+	Available uint64
+}
+
+// GetFilesystemUsage returns the size and usage of the filesystem containing the specified path
+func GetFilesystemUsage(path string) (FilesystemUsage, error) {
+	statfs := &unix.Statfs_t{}
+	err := unix.Statfs(path, statfs)
+	if err != nil {
+		return FilesystemUsage{}, err
+	}
+	// Use type conversions, as the field types vary by architecture
+	blockSize := uint64(statfs.Bsize)
+	return FilesystemUsage{
+		Size:      uint64(statfs.Blocks) * blockSize,
+		Free:      uint64(statfs.Bfree) * blockSize,
+		Available: uint64(statfs.Bavail) * blockSize,
+	}, nil
+}
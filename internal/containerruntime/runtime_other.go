@@ -1,7 +1,7 @@
 // +build !linux
 
 /*
-© Copyright IBM Corporation 2018, 2019
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -17,8 +17,22 @@ limitations under the License.
 */
 package containerruntime
 
+import "fmt"
+
 // Dummy version of this function, only for non-Linux systems.
 // Having this allows unit tests to be run on other platforms (e.g. macOS)
 func checkFS(path string) error {
 	return nil
 }
+
+// FilesystemUsage holds the size and usage of a filesystem, in bytes
+type FilesystemUsage struct {
+	Size      uint64
+	Free      uint64
+	Available uint64
+}
+
+// Dummy version of this function, only for non-Linux systems.
+func GetFilesystemUsage(path string) (FilesystemUsage, error) {
+	return FilesystemUsage{}, fmt.Errorf("Filesystem usage is not supported on this platform")
+}
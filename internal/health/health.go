@@ -0,0 +1,97 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health contains code to provide a health server for the queue manager container
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// Components of the container which report their state to the health server
+const (
+	ComponentWebServer = "webserver"
+	ComponentMetrics   = "metrics"
+)
+
+// States of a component
+const (
+	StateDisabled = "disabled"
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateStopped  = "stopped"
+	StateFailed   = "failed"
+)
+
+// ComponentStatus is the state of a component of the container
+type ComponentStatus struct {
+	State   string    `json:"state"`
+	Error   string    `json:"error,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// ErrorStatus is an error reported by runmqserver
+type ErrorStatus struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+var (
+	mutex      sync.Mutex
+	components = make(map[string]ComponentStatus)
+	lastError  *ErrorStatus
+)
+
+// SetComponentState records the state of a component, with the error which caused it to fail (if any)
+func SetComponentState(component, state string, err error) {
+	status := ComponentStatus{State: state, Updated: time.Now().UTC()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	components[component] = status
+}
+
+// SetLastError records the most recent error reported by runmqserver
+func SetLastError(message string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	lastError = &ErrorStatus{Message: message, Time: time.Now().UTC()}
+}
+
+// getComponents returns a copy of the state of all components
+func getComponents() map[string]ComponentStatus {
+	mutex.Lock()
+	defer mutex.Unlock()
+	result := make(map[string]ComponentStatus, len(components))
+	for name, status := range components {
+		result[name] = status
+	}
+	return result
+}
+
+// getLastError returns the most recent error reported by runmqserver, or nil if there is none
+func getLastError() *ErrorStatus {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if lastError == nil {
+		return nil
+	}
+	result := *lastError
+	return &result
+}
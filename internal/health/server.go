@@ -0,0 +1,145 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+const (
+	defaultPort    = 9416
+	requestTimeout = 10 * time.Second
+)
+
+var healthServer *http.Server
+
+// probeResult is the response to a liveness or readiness request
+type probeResult struct {
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	QueueManager string `json:"queueManager"`
+	State        string `json:"state"`
+	Role         string `json:"role"`
+}
+
+// Start starts the health server, if it is enabled using MQ_ENABLE_HEALTH_SERVER
+func Start(qmName string, log *logger.Logger) error {
+	enable := os.Getenv("MQ_ENABLE_HEALTH_SERVER")
+	if enable != "true" && enable != "1" {
+		return nil
+	}
+
+	port := defaultPort
+	portValue := os.Getenv("MQ_HEALTH_SERVER_PORT")
+	if portValue != "" {
+		var err error
+		port, err = strconv.Atoi(portValue)
+		if err != nil || port <= 0 || port > 65535 {
+			return fmt.Errorf("MQ_HEALTH_SERVER_PORT must be a valid port number: %s", portValue)
+		}
+	}
+
+	listeners, err := ready.GetListeners()
+	if err != nil {
+		return err
+	}
+
+	healthServer = &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           newHandler(newChecker(qmName, listeners)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := healthServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Errorf("Failed to start health server: %v", err)
+		}
+	}()
+	log.Printf("Started health server on port %d", port)
+	return nil
+}
+
+// Stop stops the health server, if it is running
+func Stop(log *logger.Logger) {
+	if healthServer == nil {
+		return
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := healthServer.Shutdown(timeout)
+	if err != nil {
+		log.Errorf("Failed to shutdown health server: %v", err)
+	}
+}
+
+// newHandler returns the handler for the health endpoints
+func newHandler(c *checker) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		status := getStatusForRequest(r, c)
+		writeProbeResult(w, status, status.live)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := getStatusForRequest(r, c)
+		writeProbeResult(w, status, status.ready)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, getStatusForRequest(r, c))
+	})
+	return mux
+}
+
+func getStatusForRequest(r *http.Request, c *checker) Status {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+	return c.getStatus(ctx)
+}
+
+// writeProbeResult writes the result of a probe, with status 200 if the probe passes, or 503 if it fails
+func writeProbeResult(w http.ResponseWriter, status Status, probe func() (bool, string)) {
+	ok, reason := probe()
+	result := probeResult{
+		Status:       "ok",
+		Reason:       reason,
+		QueueManager: status.QueueManager,
+		State:        status.State,
+		Role:         status.Role,
+	}
+	code := http.StatusOK
+	if !ok {
+		result.Status = "failed"
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, result)
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	// #nosec G104 - there is nothing to be done if the client has gone away
+	json.NewEncoder(w).Encode(value)
+}
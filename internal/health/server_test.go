@@ -0,0 +1,123 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+)
+
+func newTestChecker(state string, configured bool, dialErr error) *checker {
+	return &checker{
+		qmName:    "QM1",
+		listeners: []ready.Listener{{Port: 1414}, {Port: 1415, TLS: true}},
+		state: func(ctx context.Context, name string) (string, error) {
+			if state == "" {
+				return "", errors.New("dspmq failed")
+			}
+			return state, nil
+		},
+		configured: func() (bool, error) {
+			return configured, nil
+		},
+		dial: func(ctx context.Context, listener ready.Listener) error {
+			if listener.TLS {
+				return dialErr
+			}
+			return nil
+		},
+	}
+}
+
+func doRequest(t *testing.T, c *checker, path string) (int, map[string]interface{}) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	newHandler(c).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]interface{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &body)
+	if err != nil {
+		t.Fatalf("Failed to parse response %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, body
+}
+
+var probeTests = []struct {
+	name       string
+	state      string
+	configured bool
+	dialErr    error
+	live       int
+	ready      int
+}{
+	{"Active", "RUNNING", true, nil, http.StatusOK, http.StatusOK},
+	{"NotConfigured", "RUNNING", false, nil, http.StatusOK, http.StatusServiceUnavailable},
+	{"ListenerDown", "RUNNING", true, errors.New("connection refused"), http.StatusOK, http.StatusServiceUnavailable},
+	{"Standby", "RUNNING AS STANDBY", true, nil, http.StatusOK, http.StatusServiceUnavailable},
+	{"Replica", "REPLICA", true, nil, http.StatusOK, http.StatusServiceUnavailable},
+	{"Ended", "ENDED UNEXPECTEDLY", true, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	{"Unknown", "", true, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+}
+
+func TestProbes(t *testing.T) {
+	for _, table := range probeTests {
+		t.Run(table.name, func(t *testing.T) {
+			c := newTestChecker(table.state, table.configured, table.dialErr)
+			code, body := doRequest(t, c, "/livez")
+			if code != table.live {
+				t.Errorf("Expected /livez status %d; actual %d (%v)", table.live, code, body)
+			}
+			code, body = doRequest(t, c, "/readyz")
+			if code != table.ready {
+				t.Errorf("Expected /readyz status %d; actual %d (%v)", table.ready, code, body)
+			}
+			if code != http.StatusOK && body["reason"] == "" {
+				t.Error("Expected a reason for the failed readiness probe")
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	SetComponentState(ComponentWebServer, StateFailed, errors.New("web server failed"))
+	SetLastError("something went wrong")
+
+	code, body := doRequest(t, newTestChecker("RUNNING", true, errors.New("connection refused")), "/status")
+	if code != http.StatusOK {
+		t.Errorf("Expected status %d; actual %d", http.StatusOK, code)
+	}
+	if body["role"] != ready.RoleActive {
+		t.Errorf("Expected role %s; actual %v", ready.RoleActive, body["role"])
+	}
+	listeners, ok := body["listeners"].([]interface{})
+	if !ok || len(listeners) != 2 || listeners[0].(map[string]interface{})["reachable"] != true || listeners[1].(map[string]interface{})["reachable"] != false {
+		t.Errorf("Expected the TLS listener to be unreachable; actual %v", body["listeners"])
+	}
+	components, _ := body["components"].(map[string]interface{})
+	webServer, _ := components[ComponentWebServer].(map[string]interface{})
+	if webServer["state"] != StateFailed || webServer["error"] != "web server failed" {
+		t.Errorf("Expected failed web server; actual %v", components)
+	}
+	lastError, _ := body["lastError"].(map[string]interface{})
+	if lastError["message"] != "something went wrong" {
+		t.Errorf("Expected last error; actual %v", body["lastError"])
+	}
+}
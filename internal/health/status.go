@@ -0,0 +1,131 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+)
+
+// Status is the status of the queue manager container
+type Status struct {
+	QueueManager string                     `json:"queueManager"`
+	State        string                     `json:"state"`
+	Role         string                     `json:"role"`
+	Configured   bool                       `json:"configured"`
+	Listeners    []ListenerStatus           `json:"listeners"`
+	NativeHA     *ready.NativeHAStatus      `json:"nativeHA,omitempty"`
+	Components   map[string]ComponentStatus `json:"components"`
+	LastError    *ErrorStatus               `json:"lastError,omitempty"`
+	Time         time.Time                  `json:"time"`
+}
+
+// ListenerStatus is the result of connecting to a queue manager listener
+type ListenerStatus struct {
+	Address   string `json:"address"`
+	TLS       bool   `json:"tls"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checker gets the status of the queue manager container
+type checker struct {
+	qmName     string
+	listeners  []ready.Listener
+	state      func(ctx context.Context, name string) (string, error)
+	configured func() (bool, error)
+	dial       func(ctx context.Context, listener ready.Listener) error
+}
+
+func newChecker(qmName string, listeners []ready.Listener) *checker {
+	return &checker{
+		qmName:     qmName,
+		listeners:  listeners,
+		state:      ready.State,
+		configured: ready.Check,
+		dial:       ready.CheckListener,
+	}
+}
+
+// getStatus returns the current status of the queue manager container
+// - listeners are only checked if this instance of the queue manager is active
+func (c *checker) getStatus(ctx context.Context) Status {
+	status := Status{
+		QueueManager: c.qmName,
+		Role:         ready.RoleUnknown,
+		Listeners:    []ListenerStatus{},
+		Components:   getComponents(),
+		LastError:    getLastError(),
+		Time:         time.Now().UTC(),
+	}
+
+	state, err := c.state(ctx, c.qmName)
+	if err != nil {
+		status.State = "UNKNOWN"
+	} else {
+		status.State = state
+		status.Role = ready.StatusFromState(state).Role()
+	}
+
+	// #nosec G104 - the queue manager is not configured if the ready file can't be checked
+	status.Configured, _ = c.configured()
+
+	if status.Role == ready.RoleActive {
+		for _, l := range c.listeners {
+			listener := ListenerStatus{Address: l.Address(), TLS: l.TLS, Reachable: true}
+			err := c.dial(ctx, l)
+			if err != nil {
+				listener.Reachable = false
+				listener.Error = err.Error()
+			}
+			status.Listeners = append(status.Listeners, listener)
+		}
+	}
+
+	// #nosec G104 - the native HA status is omitted if it can't be read
+	status.NativeHA, _ = ready.ReadNativeHAStatus()
+
+	return status
+}
+
+// live returns true if the queue manager is running, in any role
+func (s Status) live() (bool, string) {
+	switch s.State {
+	case "RUNNING", "RUNNING AS STANDBY", "STARTING", "REPLICA":
+		return true, ""
+	}
+	return false, fmt.Sprintf("Queue manager state is %s", s.State)
+}
+
+// ready returns true if the queue manager is configured, active, and all configured listeners are reachable
+func (s Status) ready() (bool, string) {
+	if !s.Configured {
+		return false, "Queue manager configuration is not complete"
+	}
+	if s.Role != ready.RoleActive {
+		return false, fmt.Sprintf("Queue manager is running in %s mode", s.Role)
+	}
+	for _, listener := range s.Listeners {
+		if !listener.Reachable {
+			return false, fmt.Sprintf("Listener %s is not reachable", listener.Address)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,136 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// defaultFileMaxSize is the default size in MB at which the log file is rotated
+	defaultFileMaxSize = 10
+	// defaultFileMaxFiles is the default number of rotated log files which are kept
+	defaultFileMaxFiles = 5
+)
+
+// fileSink writes entries to a file as JSON, one entry per line.  When the file reaches the maximum size, it
+// is renamed with a numeric suffix, and the oldest rotated file is removed.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func newFileSink(path string, maxSize int64, maxFiles int) (*fileSink, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0770)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create directory for MQ_LOGGING_FILE_PATH: %v", err)
+	}
+	s := &fileSink{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	err = s.open()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) String() string {
+	return "file " + s.path
+}
+
+// open opens the log file for appending
+func (s *fileSink) open() error {
+	// #nosec G304 - the path is configured by the administrator
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		// #nosec G104 - the file hasn't been written
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = fi.Size()
+	return nil
+}
+
+// rotate renames the log file and any previously rotated files, and opens a new log file
+func (s *fileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return err
+	}
+	for i := s.maxFiles - 1; i > 0; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	err = os.Rename(s.path, s.path+".1")
+	if err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) Write(entries []Entry) (int, error) {
+	if s.file == nil {
+		err := s.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+	for i, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return i, err
+		}
+		b = append(b, '\n')
+		if s.size > 0 && s.size+int64(len(b)) > s.maxSize {
+			err = s.rotate()
+			if err != nil {
+				return i, err
+			}
+		}
+		n, err := s.file.Write(b)
+		s.size += int64(n)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
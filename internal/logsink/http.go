@@ -0,0 +1,110 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// httpTimeout is the longest time to wait for the HTTP endpoint to accept a batch
+const httpTimeout = 10 * time.Second
+
+// httpSink posts each batch of entries to an HTTP endpoint, as a JSON array
+type httpSink struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// newHTTPSink returns a sink for an HTTP endpoint.  Headers are specified as a comma-separated list of
+// key=value pairs, in the same format as MQ_METRICS_OTLP_HEADERS.
+func newHTTPSink(endpoint string, headers string) (*httpSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("MQ_LOGGING_HTTP_ENDPOINT must be an http or https URL: %s", endpoint)
+	}
+	s := &httpSink{
+		endpoint: u.String(),
+		headers:  make(map[string]string),
+		client:   &http.Client{Timeout: httpTimeout},
+	}
+	for _, header := range strings.Split(headers, ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("MQ_LOGGING_HTTP_HEADERS must be a comma-separated list of key=value pairs")
+		}
+		value, err = url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode value of header %s in MQ_LOGGING_HTTP_HEADERS: %v", key, err)
+		}
+		s.headers[strings.TrimSpace(key)] = value
+	}
+	return s, nil
+}
+
+func (s *httpSink) String() string {
+	// Only the host is included, in case the URL contains credentials
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "HTTP endpoint"
+	}
+	return "HTTP endpoint " + u.Scheme + "://" + u.Host
+}
+
+// Write sends the entries in a single request, so either all of them or none of them are sent
+func (s *httpSink) Write(entries []Entry) (int, error) {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to encode log entries: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		request.Header.Set(key, value)
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	// #nosec G104 - the response body is read so that the connection can be reused
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return 0, fmt.Errorf("Endpoint returned status %s", response.Status)
+	}
+	return len(entries), nil
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
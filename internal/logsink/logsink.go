@@ -0,0 +1,308 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logsink forwards log entries to destinations other than the container console, such as syslog,
+// a file or an HTTP endpoint
+package logsink
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+const (
+	// defaultBufferSize is the default number of entries which can be waiting to be sent to each sink
+	defaultBufferSize = 1000
+	// batchSize is the maximum number of entries sent to a sink at once
+	batchSize = 100
+	// flushInterval is the maximum time an entry waits before being sent, when a batch is not full
+	flushInterval = 1 * time.Second
+	// blockTimeout is the longest time to wait for space in a full buffer, before entries are dropped
+	blockTimeout = 5 * time.Second
+	// maxRetryInterval is the longest time between attempts to send a batch which failed
+	maxRetryInterval = 30 * time.Second
+	// closeTimeout is the longest time to spend sending buffered entries when closing
+	closeTimeout = 5 * time.Second
+)
+
+// Entry is a log entry, in the same form as the JSON messages written to the MQ error logs
+type Entry map[string]interface{}
+
+// Sink is a destination for log entries
+type Sink interface {
+	// Write sends a batch of entries, returning the number of entries which were sent, and an error if the whole
+	// batch could not be sent
+	Write(entries []Entry) (int, error)
+	// Close releases any resources used by the sink
+	Close() error
+	// String returns a description of the sink, for use in messages
+	String() string
+}
+
+// Forwarder sends log entries to a set of sinks.  Entries are buffered for each sink, so that a slow sink
+// doesn't delay the others.  When the buffer for a sink is full, Send waits for space, and entries are
+// dropped if no space becomes available.
+type Forwarder struct {
+	sinks []*bufferedSink
+}
+
+// bufferedSink queues entries for a sink, and sends them in batches
+type bufferedSink struct {
+	sink     Sink
+	queue    chan Entry
+	stop     chan struct{}
+	done     chan struct{}
+	log      *logger.Logger
+	mutex    sync.Mutex
+	dropping bool
+	dropped  int
+}
+
+// NewForwarder returns a forwarder which sends entries to the specified sinks, buffering up to the specified
+// number of entries for each sink
+func NewForwarder(sinks []Sink, bufferSize int, log *logger.Logger) *Forwarder {
+	f := &Forwarder{}
+	for _, sink := range sinks {
+		b := &bufferedSink{
+			sink:  sink,
+			queue: make(chan Entry, bufferSize),
+			stop:  make(chan struct{}),
+			done:  make(chan struct{}),
+			log:   log,
+		}
+		f.sinks = append(f.sinks, b)
+		go b.run()
+	}
+	return f
+}
+
+// Configure returns a forwarder for the sinks set in environment variables, or nil if no sinks are set
+func Configure(qmName string, log *logger.Logger) (*Forwarder, error) {
+	sinks := []Sink{}
+	closeAll := func() {
+		for _, sink := range sinks {
+			// #nosec G104 - the sinks haven't been used
+			sink.Close()
+		}
+	}
+	address := os.Getenv("MQ_LOGGING_SYSLOG_ADDRESS")
+	if address != "" {
+		sink, err := newSyslogSink(address, os.Getenv("MQ_LOGGING_SYSLOG_CA_FILE"), qmName)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	path := os.Getenv("MQ_LOGGING_FILE_PATH")
+	if path != "" {
+		maxSize, err := getPositiveInt("MQ_LOGGING_FILE_MAX_SIZE", defaultFileMaxSize)
+		if err == nil {
+			var maxFiles int
+			maxFiles, err = getPositiveInt("MQ_LOGGING_FILE_MAX_FILES", defaultFileMaxFiles)
+			if err == nil {
+				var sink Sink
+				sink, err = newFileSink(path, int64(maxSize)*1024*1024, maxFiles)
+				if err == nil {
+					sinks = append(sinks, sink)
+				}
+			}
+		}
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+	}
+	endpoint := os.Getenv("MQ_LOGGING_HTTP_ENDPOINT")
+	if endpoint != "" {
+		sink, err := newHTTPSink(endpoint, os.Getenv("MQ_LOGGING_HTTP_HEADERS"))
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	bufferSize, err := getPositiveInt("MQ_LOGGING_SINK_BUFFER_SIZE", defaultBufferSize)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	for _, sink := range sinks {
+		log.Printf("Forwarding logs to %v", sink)
+	}
+	return NewForwarder(sinks, bufferSize, log), nil
+}
+
+// getPositiveInt returns the value of an environment variable which must be a positive integer
+func getPositiveInt(name string, defaultValue int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("%v must be a positive integer: %v", name, value)
+	}
+	return i, nil
+}
+
+// Send queues an entry to be sent to every sink
+func (f *Forwarder) Send(entry Entry) {
+	for _, b := range f.sinks {
+		b.send(entry)
+	}
+}
+
+// Close sends any buffered entries, and closes the sinks
+func (f *Forwarder) Close() {
+	for _, b := range f.sinks {
+		close(b.stop)
+	}
+	for _, b := range f.sinks {
+		<-b.done
+		err := b.sink.Close()
+		if err != nil {
+			b.log.Errorf("Failed to close log sink %v: %v", b.sink, err)
+		}
+	}
+}
+
+// send adds an entry to the queue, waiting if the queue is full.  While the sink is unable to keep up,
+// entries which don't fit in the queue are dropped without waiting.
+func (b *bufferedSink) send(entry Entry) {
+	select {
+	case b.queue <- entry:
+		return
+	default:
+	}
+	b.mutex.Lock()
+	dropping := b.dropping
+	b.mutex.Unlock()
+	if !dropping {
+		timer := time.NewTimer(blockTimeout)
+		defer timer.Stop()
+		select {
+		case b.queue <- entry:
+			return
+		case <-b.stop:
+			return
+		case <-timer.C:
+		}
+	}
+	b.mutex.Lock()
+	b.dropping = true
+	b.dropped++
+	b.mutex.Unlock()
+}
+
+// run sends batches of entries to the sink, until the forwarder is closed
+func (b *bufferedSink) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]Entry, 0, batchSize)
+	for {
+		select {
+		case entry := <-b.queue:
+			batch = append(batch, entry)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-b.stop:
+			b.flush(batch)
+			return
+		}
+		if !b.write(batch) {
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// write sends a batch to the sink, retrying the entries which weren't sent until it succeeds.  False is returned
+// if the forwarder is closed while retrying.
+func (b *bufferedSink) write(batch []Entry) bool {
+	retryInterval := flushInterval
+	failed := false
+	for {
+		n, err := b.sink.Write(batch)
+		if err == nil {
+			break
+		}
+		batch = batch[n:]
+		if !failed {
+			b.log.Errorf("Failed to send logs to %v: %v", b.sink, err)
+			failed = true
+		}
+		select {
+		case <-time.After(retryInterval):
+		case <-b.stop:
+			b.flush(batch)
+			return false
+		}
+		retryInterval *= 2
+		if retryInterval > maxRetryInterval {
+			retryInterval = maxRetryInterval
+		}
+	}
+	b.mutex.Lock()
+	dropped := b.dropped
+	b.dropping = false
+	b.dropped = 0
+	b.mutex.Unlock()
+	if failed || dropped > 0 {
+		b.log.Printf("Resumed sending logs to %v.  %v log entries were dropped", b.sink, dropped)
+	}
+	return true
+}
+
+// flush makes a last attempt to send the batch and any queued entries, before the sink is closed
+func (b *bufferedSink) flush(batch []Entry) {
+	deadline := time.Now().Add(closeTimeout)
+	for {
+	drain:
+		for len(batch) < batchSize {
+			select {
+			case entry := <-b.queue:
+				batch = append(batch, entry)
+			default:
+				break drain
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+		_, err := b.sink.Write(batch)
+		if err != nil || time.Now().After(deadline) {
+			if err != nil {
+				b.log.Errorf("Failed to send logs to %v: %v", b.sink, err)
+			}
+			return
+		}
+		batch = batch[:0]
+	}
+}
@@ -0,0 +1,329 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logsink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+func getTestLogger(t *testing.T) *logger.Logger {
+	log, err := logger.NewLogger(new(bytes.Buffer), false, false, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+var syslogFormatTests = []struct {
+	entry    Entry
+	expected string
+}{
+	{
+		Entry{"ibm_datetime": "2023-06-24T10:00:00.123Z", "host": "qm1-0", "type": "mq_log", "ibm_processId": "123", "ibm_messageId": "AMQ9999E", "message": "AMQ9999E: Channel ended abnormally."},
+		"<131>1 2023-06-24T10:00:00.123Z qm1-0 mq_log 123 AMQ9999E [ibmmq@2 qmgr=\"QM1\"] AMQ9999E: Channel ended abnormally.",
+	},
+	{
+		Entry{"ibm_datetime": "2023-06-24T10:00:00.123+0000", "host": "qm1-0", "type": "liberty_message", "loglevel": "WARNING", "message": "Warning"},
+		"<132>1 2023-06-24T10:00:00.123Z qm1-0 liberty_message - - [ibmmq@2 qmgr=\"QM1\"] Warning",
+	},
+	{
+		Entry{"host": "qm1 0", "loglevel": "DEBUG", "message": "Debug"},
+		"<135>1 2023-01-01T00:00:00.000Z qm10 - - - [ibmmq@2 qmgr=\"QM1\"] Debug",
+	},
+}
+
+func TestSyslogFormat(t *testing.T) {
+	s, err := newSyslogSink("udp://localhost:514", "", "QM1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, table := range syslogFormatTests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			msg := s.format(table.entry, now)
+			if msg != table.expected {
+				t.Errorf("Expected %v; got %v", table.expected, msg)
+			}
+		})
+	}
+}
+
+func TestSyslogInvalidAddress(t *testing.T) {
+	for _, address := range []string{"localhost:514", "http://localhost:514", "tls://localhost"} {
+		_, err := newSyslogSink(address, "", "QM1")
+		if err == nil {
+			t.Errorf("Expected error for syslog address %v", address)
+		}
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var length int
+			_, err := fmt.Fscanf(r, "%d ", &length)
+			if err != nil {
+				return
+			}
+			msg := make([]byte, length)
+			_, err = io.ReadFull(r, msg)
+			if err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+	s, err := newSyslogSink("tcp://"+listener.Addr().String(), "", "QM1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_, err = s.Write([]Entry{{"message": "First"}, {"message": "Second message"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"First", "Second message"} {
+		select {
+		case msg := <-received:
+			if !strings.HasSuffix(msg, "] "+expected) {
+				t.Errorf("Expected message ending with %v; got %v", expected, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message %v", expected)
+		}
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "container.log")
+	s, err := newFileSink(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < 10; i++ {
+		_, err = s.Write([]Entry{{"message": fmt.Sprintf("Message number %v", i)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 100 {
+			t.Errorf("Expected %v to be no bigger than 100 bytes; got %v", name, fi.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only two rotated files to be kept")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Message number 9") {
+		t.Errorf("Expected latest message in %v; got %v", path, string(b))
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var batches [][]Entry
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		var batch []Entry
+		err := json.NewDecoder(r.Body).Decode(&batch)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+	s, err := newHTTPSink(server.URL, "Authorization=Bearer%20abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Write([]Entry{{"message": "One"}, {"message": "Two"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 || batches[0][1]["message"] != "Two" {
+		t.Errorf("Expected one batch of two entries; got %v", batches)
+	}
+	if authorization != "Bearer abc" {
+		t.Errorf("Expected Authorization header to be set; got %v", authorization)
+	}
+}
+
+func TestHTTPSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	s, err := newHTTPSink(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Write([]Entry{{"message": "One"}})
+	if err == nil {
+		t.Error("Expected error when endpoint returns status 503")
+	}
+}
+
+// testSink records the entries written to it.  When fail is set, the next write returns an error after writing
+// up to partial entries.
+type testSink struct {
+	mutex   sync.Mutex
+	entries []Entry
+	fail    bool
+	partial int
+}
+
+func (s *testSink) Write(entries []Entry) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.fail {
+		n := s.partial
+		if n > len(entries) {
+			n = len(entries)
+		}
+		s.entries = append(s.entries, entries[:n]...)
+		s.fail = false
+		return n, errors.New("sink unavailable")
+	}
+	s.entries = append(s.entries, entries...)
+	return len(entries), nil
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+func (s *testSink) String() string {
+	return "test sink"
+}
+
+func (s *testSink) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+func TestForwarderFlushOnClose(t *testing.T) {
+	sink := &testSink{}
+	f := NewForwarder([]Sink{sink}, 10, getTestLogger(t))
+	for i := 0; i < 5; i++ {
+		f.Send(Entry{"message": fmt.Sprintf("%v", i)})
+	}
+	f.Close()
+	if sink.count() != 5 {
+		t.Errorf("Expected 5 entries to be written when closing; got %v", sink.count())
+	}
+}
+
+func TestForwarderDropsWhenFull(t *testing.T) {
+	sink := &testSink{fail: true}
+	b := &bufferedSink{
+		sink:  sink,
+		queue: make(chan Entry, 2),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		log:   getTestLogger(t),
+	}
+	// Mark the sink as unable to keep up, so that entries are dropped without waiting
+	b.dropping = true
+	for i := 0; i < 5; i++ {
+		b.send(Entry{"message": fmt.Sprintf("%v", i)})
+	}
+	if len(b.queue) != 2 || b.dropped != 3 {
+		t.Errorf("Expected 2 entries to be queued and 3 dropped; got %v and %v", len(b.queue), b.dropped)
+	}
+	sink.fail = false
+	if !b.write([]Entry{<-b.queue, <-b.queue}) {
+		t.Fatal("Expected write to succeed")
+	}
+	if b.dropping || b.dropped != 0 {
+		t.Errorf("Expected dropping to stop after a successful write")
+	}
+}
+
+func TestForwarderRetriesUnsentEntries(t *testing.T) {
+	sink := &testSink{fail: true, partial: 2}
+	b := &bufferedSink{
+		sink:  sink,
+		queue: make(chan Entry, 10),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		log:   getTestLogger(t),
+	}
+	batch := []Entry{{"message": "0"}, {"message": "1"}, {"message": "2"}, {"message": "3"}}
+	if !b.write(batch) {
+		t.Fatal("Expected write to succeed")
+	}
+	if len(sink.entries) != len(batch) {
+		t.Fatalf("Expected %v entries to be written once each; got %v", len(batch), sink.entries)
+	}
+	for i, entry := range sink.entries {
+		if entry["message"] != batch[i]["message"] {
+			t.Errorf("Expected entry %v to be %v; got %v", i, batch[i], entry)
+		}
+	}
+}
+
+func TestFileSinkPartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "container.log")
+	s, err := newFileSink(path, 1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// An entry which can't be encoded stops the batch, after the entries before it are written
+	n, err := s.Write([]Entry{{"message": "One"}, {"message": "Two"}, {"message": make(chan int)}, {"message": "Four"}})
+	if err == nil || n != 2 {
+		t.Errorf("Expected an error after writing 2 entries; got %v, %v", n, err)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logsink
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// syslogFacility is the facility used for all messages (local0)
+	syslogFacility = 16
+	// syslogTimeout is the longest time to wait to connect to, or write to, the syslog server
+	syslogTimeout = 10 * time.Second
+	// syslogTimestampFormat is the RFC 5424 timestamp format, with milliseconds
+	syslogTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+	// syslogStructuredDataID identifies the structured data added to each message
+	syslogStructuredDataID = "ibmmq@2"
+)
+
+// Syslog severities
+const (
+	syslogCritical      = 2
+	syslogError         = 3
+	syslogWarning       = 4
+	syslogInformational = 6
+	syslogDebug         = 7
+)
+
+// syslogSink sends entries to a syslog server, using the RFC 5424 format.  Messages are sent as one datagram
+// each over UDP, or using octet counting framing (RFC 6587) over TCP and TLS.
+type syslogSink struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	qmName    string
+	hostname  string
+	conn      net.Conn
+}
+
+// newSyslogSink returns a sink for a syslog server address, in the form udp://host:port, tcp://host:port
+// or tls://host:port.  If a CA file is specified, it is used instead of the system certificates to
+// verify the server certificate.
+func newSyslogSink(address string, caFile string, qmName string) (*syslogSink, error) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("MQ_LOGGING_SYSLOG_ADDRESS must be a URL of the form udp://host:port, tcp://host:port or tls://host:port: %v", address)
+	}
+	// #nosec G104 - the host name is taken from each entry if it is not available
+	hostname, _ := os.Hostname()
+	s := &syslogSink{
+		address:  u.Host,
+		qmName:   qmName,
+		hostname: hostname,
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		s.network = u.Scheme
+	case "tls":
+		s.network = "tcp"
+		host, _, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return nil, fmt.Errorf("MQ_LOGGING_SYSLOG_ADDRESS must include a port: %v", address)
+		}
+		s.tlsConfig = &tls.Config{
+			ServerName: host,
+			MinVersion: tls.VersionTLS12,
+		}
+		if caFile != "" {
+			// #nosec G304 - the CA file is configured by the administrator
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read MQ_LOGGING_SYSLOG_CA_FILE: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("No certificates found in MQ_LOGGING_SYSLOG_CA_FILE: %v", caFile)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	default:
+		return nil, fmt.Errorf("MQ_LOGGING_SYSLOG_ADDRESS must use the udp, tcp or tls scheme: %v", address)
+	}
+	return s, nil
+}
+
+func (s *syslogSink) String() string {
+	if s.tlsConfig != nil {
+		return "syslog server tls://" + s.address
+	}
+	return "syslog server " + s.network + "://" + s.address
+}
+
+// connect connects to the syslog server, if not already connected
+func (s *syslogSink) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	var err error
+	if s.tlsConfig != nil {
+		s.conn, err = tls.DialWithDialer(dialer, s.network, s.address, s.tlsConfig)
+	} else {
+		s.conn, err = dialer.Dial(s.network, s.address)
+	}
+	return err
+}
+
+// Write sends each entry as a syslog message.  The connection is closed after a failure, and opened again
+// for the next batch.
+func (s *syslogSink) Write(entries []Entry) (int, error) {
+	err := s.connect()
+	if err != nil {
+		return 0, err
+	}
+	for i, entry := range entries {
+		msg := s.format(entry, time.Now())
+		if s.network == "tcp" {
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+		// #nosec G104 - an error is reported by the write if the deadline can't be set
+		s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		_, err = s.conn.Write([]byte(msg))
+		if err != nil {
+			// #nosec G104 - the connection has already failed
+			s.conn.Close()
+			s.conn = nil
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format returns the RFC 5424 message for an entry
+func (s *syslogSink) format(entry Entry, now time.Time) string {
+	timestamp := now
+	if datetime, ok := entry["ibm_datetime"].(string); ok {
+		timestamp = parseTimestamp(datetime, now)
+	}
+	hostname := stringField(entry, "host")
+	if hostname == "" {
+		hostname = s.hostname
+	}
+	structuredData := "-"
+	if s.qmName != "" {
+		structuredData = fmt.Sprintf("[%s qmgr=\"%s\"]", syslogStructuredDataID, escapeParamValue(s.qmName))
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		syslogFacility*8+syslogSeverity(entry),
+		timestamp.Format(syslogTimestampFormat),
+		headerField(hostname, 255),
+		headerField(stringField(entry, "type"), 48),
+		headerField(stringField(entry, "ibm_processId"), 128),
+		headerField(stringField(entry, "ibm_messageId"), 32),
+		structuredData,
+		stringField(entry, "message"))
+}
+
+// parseTimestamp parses the timestamp of an entry, which might use a time zone offset without a colon
+// (for example in Liberty logs)
+func parseTimestamp(datetime string, defaultTime time.Time) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700"} {
+		t, err := time.Parse(layout, datetime)
+		if err == nil {
+			return t
+		}
+	}
+	return defaultTime
+}
+
+// syslogSeverity returns the syslog severity of an entry, from the suffix of the message ID, or from the
+// log level if the entry has no message ID
+func syslogSeverity(entry Entry) int {
+	id := stringField(entry, "ibm_messageId")
+	if len(id) > 0 {
+		switch strings.ToUpper(id)[len(id)-1] {
+		case 'I', 'A':
+			return syslogInformational
+		case 'W':
+			return syslogWarning
+		case 'E':
+			return syslogError
+		case 'S':
+			return syslogCritical
+		}
+	}
+	switch strings.ToUpper(stringField(entry, "loglevel")) {
+	case "WARNING", "WARN":
+		return syslogWarning
+	case "ERROR":
+		return syslogError
+	case "SEVERE", "FATAL":
+		return syslogCritical
+	case "DEBUG", "FINE", "FINER", "FINEST", "ENTRY", "EXIT":
+		return syslogDebug
+	}
+	return syslogInformational
+}
+
+// stringField returns the value of a field in an entry, or an empty string if it is not a string
+func stringField(entry Entry, name string) string {
+	s, _ := entry[name].(string)
+	return s
+}
+
+// headerField returns a value for a syslog header field, which must be printable ASCII without spaces,
+// or "-" if the value is empty
+func headerField(value string, maxLength int) string {
+	b := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(b) < maxLength; i++ {
+		if value[i] > 32 && value[i] < 127 {
+			b = append(b, value[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// escapeParamValue escapes the characters which are not allowed in a structured data parameter value
+func escapeParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
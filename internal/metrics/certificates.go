@@ -0,0 +1,84 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/tls"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	certificatePrefix = "certificate"
+	labelLabel        = "label"
+	subjectLabel      = "subject"
+	storeLabel        = "store"
+)
+
+var (
+	certificatesMutex sync.Mutex
+	certificates      []tls.CertificateInfo
+)
+
+// SetCertificates sets the certificates which expiry metrics are provided for
+func SetCertificates(certs []tls.CertificateInfo) {
+	certificatesMutex.Lock()
+	defer certificatesMutex.Unlock()
+	certificates = certs
+}
+
+func getCertificates() []tls.CertificateInfo {
+	certificatesMutex.Lock()
+	defer certificatesMutex.Unlock()
+	return certificates
+}
+
+// certificateCollector provides metrics for the expiry of the certificates set by runmqserver
+type certificateCollector struct {
+	qmName       string
+	certificates func() []tls.CertificateInfo
+	now          func() time.Time
+	expiryDesc   *prometheus.Desc
+}
+
+func newCertificateCollector(qmName string) *certificateCollector {
+	return &certificateCollector{
+		qmName:       qmName,
+		certificates: getCertificates,
+		now:          time.Now,
+		expiryDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, certificatePrefix, "expiry_seconds"),
+			"Time until each certificate in the queue manager, native HA and web keystores expires (negative once it has expired)",
+			[]string{labelLabel, subjectLabel, storeLabel, qmgrLabel},
+			nil,
+		),
+	}
+}
+
+// Describe provides details of the certificate metrics
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.expiryDesc
+}
+
+// Collect provides the time until each certificate expires
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.now()
+	for _, certificate := range c.certificates() {
+		ch <- prometheus.MustNewConstMetric(c.expiryDesc, prometheus.GaugeValue, certificate.NotAfter.Sub(now).Seconds(), certificate.Label, certificate.Subject, certificate.Store, c.qmName)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/tls"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCertificateCollector(t *testing.T) {
+
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	collector := newCertificateCollector("QM1")
+	collector.now = func() time.Time { return now }
+	collector.certificates = func() []tls.CertificateInfo {
+		return []tls.CertificateInfo{
+			{Store: tls.StoreQueueManager, Label: "default", Subject: "CN=qm1", NotAfter: now.Add(time.Hour)},
+			{Store: tls.StoreWeb, Label: "trust", Subject: "CN=ca", NotAfter: now.Add(-time.Minute)},
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "ibmmq_certificate_expiry_seconds" {
+			t.Errorf("Unexpected metric %s", family.GetName())
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels[qmgrLabel] != "QM1" {
+				t.Errorf("Expected qmgr=QM1; actual %s", labels[qmgrLabel])
+			}
+			values[labels[storeLabel]+"/"+labels[labelLabel]+"/"+labels[subjectLabel]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	if values["qmgr/default/CN=qm1"] != 3600 {
+		t.Errorf("Expected expiry=%d; actual %f", 3600, values["qmgr/default/CN=qm1"])
+	}
+	if values["web/trust/CN=ca"] != -60 {
+		t.Errorf("Expected expiry=%d; actual %f", -60, values["web/trust/CN=ca"])
+	}
+}
@@ -0,0 +1,226 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/ibmmq"
+)
+
+const (
+	channelStatusClass = "CHSTATUS"
+	channelPrefix      = "channel"
+	channelLabel       = "channel"
+	channelTypeLabel   = "type"
+	connectionLabel    = "connection_name"
+)
+
+// channelStatusMetric describes a metric obtained from the status of each channel instance
+type channelStatusMetric struct {
+	parameter   int32
+	description string
+	name        string
+}
+
+// channelStatusMetrics are the per-channel metrics obtained using the 'Inquire Channel Status' command
+var channelStatusMetrics = []channelStatusMetric{
+	{ibmmq.MQIACH_CHANNEL_STATUS, "Channel status (1=binding, 2=starting, 3=running, 4=stopping, 5=retrying, 6=stopped, 7=requesting, 8=paused, 9=disconnected, 13=initializing, 14=switching)", "status"},
+	{ibmmq.MQIACH_MSGS, "Messages sent or received", "messages"},
+	{ibmmq.MQIACH_BYTES_SENT, "Bytes sent", "bytes_sent"},
+	{ibmmq.MQIACH_BYTES_RECEIVED, "Bytes received", "bytes_received"},
+	{ibmmq.MQIACH_BATCHES, "Completed batches", "batches"},
+	{ibmmq.MQIACH_INDOUBT_STATUS, "In-doubt status (1=in-doubt)", "indoubt"},
+}
+
+// channelInstancesMetric is the number of current instances of each channel, which is counted rather than
+// obtained from a status attribute
+var channelInstancesMetric = channelStatusMetric{0, "Current channel instances", "instances"}
+
+// allChannelStatusMetrics are all of the channel status metrics, including the number of instances
+var allChannelStatusMetrics = append(append([]channelStatusMetric{}, channelStatusMetrics...), channelInstancesMetric)
+
+// channelTypes maps channel types to the names used for the channel type label
+var channelTypes = map[int64]string{
+	int64(ibmmq.MQCHT_SENDER):    "SDR",
+	int64(ibmmq.MQCHT_SERVER):    "SVR",
+	int64(ibmmq.MQCHT_RECEIVER):  "RCVR",
+	int64(ibmmq.MQCHT_REQUESTER): "RQSTR",
+	int64(ibmmq.MQCHT_CLNTCONN):  "CLNTCONN",
+	int64(ibmmq.MQCHT_SVRCONN):   "SVRCONN",
+	int64(ibmmq.MQCHT_CLUSRCVR):  "CLUSRCVR",
+	int64(ibmmq.MQCHT_CLUSSDR):   "CLUSSDR",
+	int64(ibmmq.MQCHT_MQTT):      "MQTT",
+	int64(ibmmq.MQCHT_AMQP):      "AMQP",
+}
+
+// channelInstance holds the status of a single channel instance
+type channelInstance struct {
+	name       string
+	chlType    string
+	connection string
+	values     map[int32]int64
+}
+
+// initialiseChannelStatusMetrics adds the metrics obtained from the channel status
+func initialiseChannelStatusMetrics(metrics map[string]*metricData) {
+	for _, statusMetric := range channelStatusMetrics {
+		metrics[makeChannelStatusKey(statusMetric)] = &metricData{
+			name:        statusMetric.name,
+			description: statusMetric.description,
+			prefix:      channelPrefix,
+			labels:      []string{channelLabel, channelTypeLabel, connectionLabel},
+			values:      make(map[string]float64),
+			labelValues: make(map[string][]string),
+		}
+	}
+	metrics[makeChannelStatusKey(channelInstancesMetric)] = &metricData{
+		name:        channelInstancesMetric.name,
+		description: channelInstancesMetric.description,
+		prefix:      channelPrefix,
+		labels:      []string{channelLabel, channelTypeLabel},
+		values:      make(map[string]float64),
+		labelValues: make(map[string][]string),
+	}
+}
+
+// updateChannelStatusMetrics updates the metrics obtained from the status of each selected channel
+func updateChannelStatusMetrics(metrics map[string]*metricData, conn *commandConnection, selection objectSelection) error {
+	attrs := []int32{ibmmq.MQCACH_CHANNEL_NAME, ibmmq.MQCACH_CONNECTION_NAME, ibmmq.MQIACH_CHANNEL_TYPE}
+	for _, statusMetric := range channelStatusMetrics {
+		attrs = append(attrs, statusMetric.parameter)
+	}
+
+	// Channels matching more than one pattern are only included once
+	instances := []channelInstance{}
+	found := make(map[string]bool)
+	for _, pattern := range selection.include {
+		responses, err := conn.sendCommand(ibmmq.MQCMD_INQUIRE_CHANNEL_STATUS, [][]byte{
+			pcfString(ibmmq.MQCACH_CHANNEL_NAME, pattern),
+			pcfIntegerList(ibmmq.MQIACH_CHANNEL_INSTANCE_ATTRS, attrs),
+		})
+		if err != nil {
+			return err
+		}
+		matched := make(map[string]bool)
+		for _, response := range responses {
+			if response.header.CompCode != ibmmq.MQCC_OK {
+				// No status is available when none of the matching channels have been started
+				if response.header.Reason == ibmmq.MQRCCF_CHL_STATUS_NOT_FOUND {
+					continue
+				}
+				return fmt.Errorf("Failed to inquire status of channels matching '%s': reason %d", pattern, response.header.Reason)
+			}
+			instance := parseChannelInstance(response.parameters)
+			if instance.name == "" || found[instance.name] || selection.excluded(instance.name) {
+				continue
+			}
+			matched[instance.name] = true
+			instances = append(instances, instance)
+		}
+		for name := range matched {
+			found[name] = true
+		}
+	}
+
+	setChannelStatusValues(metrics, instances)
+	return nil
+}
+
+// parseChannelInstance returns the status of a channel instance, from the parameters of a channel status response
+func parseChannelInstance(parameters []*ibmmq.PCFParameter) channelInstance {
+	instance := channelInstance{values: make(map[int32]int64)}
+	for _, parameter := range parameters {
+		switch parameter.Parameter {
+		case ibmmq.MQCACH_CHANNEL_NAME:
+			if len(parameter.String) > 0 {
+				instance.name = strings.TrimSpace(parameter.String[0])
+			}
+		case ibmmq.MQCACH_CONNECTION_NAME:
+			if len(parameter.String) > 0 {
+				instance.connection = strings.TrimSpace(parameter.String[0])
+			}
+		case ibmmq.MQIACH_CHANNEL_TYPE:
+			if len(parameter.Int64Value) > 0 {
+				instance.chlType = channelTypes[parameter.Int64Value[0]]
+			}
+		default:
+			if len(parameter.Int64Value) > 0 {
+				instance.values[parameter.Parameter] = parameter.Int64Value[0]
+			}
+		}
+	}
+	return instance
+}
+
+// setChannelStatusValues sets the metric values for all channel instances
+//   - instances with the same channel name, type and connection name are combined, for example
+//     when a client application makes several connections from the same address
+func setChannelStatusValues(metrics map[string]*metricData, instances []channelInstance) {
+	for _, statusMetric := range allChannelStatusMetrics {
+		if metric, ok := metrics[makeChannelStatusKey(statusMetric)]; ok {
+			metric.values = make(map[string]float64)
+			metric.labelValues = make(map[string][]string)
+		}
+	}
+
+	for _, instance := range instances {
+		labelValues := []string{instance.name, instance.chlType, instance.connection}
+		label := strings.Join(labelValues, ",")
+
+		for _, statusMetric := range channelStatusMetrics {
+			metric, ok := metrics[makeChannelStatusKey(statusMetric)]
+			if !ok {
+				continue
+			}
+			value, ok := instance.values[statusMetric.parameter]
+			if !ok || value < 0 {
+				continue
+			}
+			existing, combined := metric.values[label]
+			switch {
+			case !combined:
+				metric.values[label] = float64(value)
+			case statusMetric.parameter == ibmmq.MQIACH_CHANNEL_STATUS:
+				// Report any instance which is not running, so that problems are not hidden
+				if value != int64(ibmmq.MQCHS_RUNNING) {
+					metric.values[label] = float64(value)
+				}
+			case statusMetric.parameter == ibmmq.MQIACH_INDOUBT_STATUS:
+				if value == int64(ibmmq.MQCHIDS_INDOUBT) {
+					metric.values[label] = float64(value)
+				}
+			default:
+				metric.values[label] = existing + float64(value)
+			}
+			metric.labelValues[label] = labelValues
+		}
+
+		if metric, ok := metrics[makeChannelStatusKey(channelInstancesMetric)]; ok {
+			label := strings.Join(labelValues[:2], ",")
+			metric.values[label]++
+			metric.labelValues[label] = labelValues[:2]
+		}
+	}
+}
+
+// makeChannelStatusKey builds a unique key for each channel status metric
+func makeChannelStatusKey(statusMetric channelStatusMetric) string {
+	return channelStatusClass + "/" + statusMetric.description
+}
@@ -0,0 +1,82 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang/ibmmq"
+)
+
+func channelStatusParameters(name string, chlType int32, connection string, status int32, msgs int64) []*ibmmq.PCFParameter {
+	return []*ibmmq.PCFParameter{
+		{Type: ibmmq.MQCFT_STRING, Parameter: ibmmq.MQCACH_CHANNEL_NAME, String: []string{name + "    "}},
+		{Type: ibmmq.MQCFT_STRING, Parameter: ibmmq.MQCACH_CONNECTION_NAME, String: []string{connection + "    "}},
+		{Type: ibmmq.MQCFT_INTEGER, Parameter: ibmmq.MQIACH_CHANNEL_TYPE, Int64Value: []int64{int64(chlType)}},
+		{Type: ibmmq.MQCFT_INTEGER, Parameter: ibmmq.MQIACH_CHANNEL_STATUS, Int64Value: []int64{int64(status)}},
+		{Type: ibmmq.MQCFT_INTEGER, Parameter: ibmmq.MQIACH_MSGS, Int64Value: []int64{msgs}},
+	}
+}
+
+func TestParseChannelInstance(t *testing.T) {
+	instance := parseChannelInstance(channelStatusParameters("TO.QM2", ibmmq.MQCHT_SENDER, "qm2(1414)", ibmmq.MQCHS_RETRYING, 5))
+
+	if instance.name != "TO.QM2" {
+		t.Errorf("Expected channel name=%s; actual %s", "TO.QM2", instance.name)
+	}
+	if instance.chlType != "SDR" {
+		t.Errorf("Expected channel type=%s; actual %s", "SDR", instance.chlType)
+	}
+	if instance.connection != "qm2(1414)" {
+		t.Errorf("Expected connection name=%s; actual %s", "qm2(1414)", instance.connection)
+	}
+	if instance.values[ibmmq.MQIACH_CHANNEL_STATUS] != int64(ibmmq.MQCHS_RETRYING) {
+		t.Errorf("Expected channel status=%d; actual %d", ibmmq.MQCHS_RETRYING, instance.values[ibmmq.MQIACH_CHANNEL_STATUS])
+	}
+}
+
+func TestSetChannelStatusValues(t *testing.T) {
+	metrics := make(map[string]*metricData)
+	initialiseChannelStatusMetrics(metrics)
+
+	if len(metrics) != len(channelStatusMetrics)+1 {
+		t.Fatalf("Expected %d channel status metrics; actual %d", len(channelStatusMetrics)+1, len(metrics))
+	}
+
+	instances := []channelInstance{
+		parseChannelInstance(channelStatusParameters("APP.SVRCONN", ibmmq.MQCHT_SVRCONN, "10.0.0.1", ibmmq.MQCHS_RUNNING, 2)),
+		parseChannelInstance(channelStatusParameters("APP.SVRCONN", ibmmq.MQCHT_SVRCONN, "10.0.0.1", ibmmq.MQCHS_STOPPING, 3)),
+		parseChannelInstance(channelStatusParameters("APP.SVRCONN", ibmmq.MQCHT_SVRCONN, "10.0.0.2", ibmmq.MQCHS_RUNNING, 4)),
+	}
+	setChannelStatusValues(metrics, instances)
+
+	label := "APP.SVRCONN,SVRCONN,10.0.0.1"
+	status := metrics[makeChannelStatusKey(channelStatusMetrics[0])]
+	if status.values[label] != float64(ibmmq.MQCHS_STOPPING) {
+		t.Errorf("Expected channel status=%d; actual %f", ibmmq.MQCHS_STOPPING, status.values[label])
+	}
+	messages := metrics[makeChannelStatusKey(channelStatusMetrics[1])]
+	if messages.values[label] != 5 {
+		t.Errorf("Expected messages=%f; actual %f", float64(5), messages.values[label])
+	}
+	if len(messages.labelValues[label]) != 3 || messages.labelValues[label][2] != "10.0.0.1" {
+		t.Errorf("Expected label values for connection 10.0.0.1; actual %v", messages.labelValues[label])
+	}
+	instancesMetric := metrics[makeChannelStatusKey(channelInstancesMetric)]
+	if instancesMetric.values["APP.SVRCONN,SVRCONN"] != 3 {
+		t.Errorf("Expected instances=%f; actual %f", float64(3), instancesMetric.values["APP.SVRCONN,SVRCONN"])
+	}
+}
@@ -0,0 +1,207 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang/ibmmq"
+)
+
+const (
+	commandQueueName = "SYSTEM.ADMIN.COMMAND.QUEUE"
+	replyModelQueue  = "SYSTEM.DEFAULT.MODEL.QUEUE"
+	replyDynamicName = "AMQ.MQCONTAINER.*"
+	commandWait      = 5
+	commandBufferLen = 65536
+)
+
+// commandConnection holds a connection to the queue manager which is used to send
+// PCF commands to the command server, and receive the responses
+type commandConnection struct {
+	qMgr      ibmmq.MQQueueManager
+	cmdQObj   ibmmq.MQObject
+	replyQObj ibmmq.MQObject
+	connected bool
+	cmdOpen   bool
+	replyOpen bool
+	buffer    []byte
+}
+
+// pcfResponse holds the header and parameters from a single PCF response message
+type pcfResponse struct {
+	header     *ibmmq.MQCFH
+	parameters []*ibmmq.PCFParameter
+}
+
+// newCommandConnection connects to the queue manager, and opens the command queue and a dynamic reply queue
+func newCommandConnection(qmName string) (*commandConnection, error) {
+	conn := &commandConnection{buffer: make([]byte, commandBufferLen)}
+
+	cno := ibmmq.NewMQCNO()
+	cno.Options = ibmmq.MQCNO_LOCAL_BINDING | ibmmq.MQCNO_HANDLE_SHARE_BLOCK
+
+	var err error
+	conn.qMgr, err = ibmmq.Connx(qmName, cno)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to queue manager %s: %v", qmName, err)
+	}
+	conn.connected = true
+
+	od := ibmmq.NewMQOD()
+	od.ObjectType = ibmmq.MQOT_Q
+	od.ObjectName = commandQueueName
+	conn.cmdQObj, err = conn.qMgr.Open(od, ibmmq.MQOO_OUTPUT|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		conn.close()
+		return nil, fmt.Errorf("Failed to open queue %s: %v", commandQueueName, err)
+	}
+	conn.cmdOpen = true
+
+	od = ibmmq.NewMQOD()
+	od.ObjectType = ibmmq.MQOT_Q
+	od.ObjectName = replyModelQueue
+	od.DynamicQName = replyDynamicName
+	conn.replyQObj, err = conn.qMgr.Open(od, ibmmq.MQOO_INPUT_EXCLUSIVE|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		conn.close()
+		return nil, fmt.Errorf("Failed to open reply queue from %s: %v", replyModelQueue, err)
+	}
+	conn.replyOpen = true
+
+	return conn, nil
+}
+
+// close closes the queues and disconnects from the queue manager
+func (c *commandConnection) close() {
+	if c == nil {
+		return
+	}
+	if c.replyOpen {
+		// #nosec G104
+		c.replyQObj.Close(ibmmq.MQCO_DELETE_PURGE)
+		c.replyOpen = false
+	}
+	if c.cmdOpen {
+		// #nosec G104
+		c.cmdQObj.Close(ibmmq.MQCO_NONE)
+		c.cmdOpen = false
+	}
+	if c.connected {
+		// #nosec G104
+		c.qMgr.Disc()
+		c.connected = false
+	}
+}
+
+// sendCommand puts a PCF command to the command server, and returns all of the responses
+func (c *commandConnection) sendCommand(command int32, parameters [][]byte) ([]pcfResponse, error) {
+
+	cfh := ibmmq.NewMQCFH()
+	cfh.Command = command
+
+	var buf []byte
+	for _, parameter := range parameters {
+		cfh.ParameterCount++
+		buf = append(buf, parameter...)
+	}
+	buf = append(cfh.Bytes(), buf...)
+
+	putmqmd := ibmmq.NewMQMD()
+	pmo := ibmmq.NewMQPMO()
+	pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT | ibmmq.MQPMO_NEW_MSG_ID | ibmmq.MQPMO_NEW_CORREL_ID | ibmmq.MQPMO_FAIL_IF_QUIESCING
+	putmqmd.Format = "MQADMIN"
+	putmqmd.ReplyToQ = c.replyQObj.Name
+	putmqmd.MsgType = ibmmq.MQMT_REQUEST
+	putmqmd.Report = ibmmq.MQRO_PASS_DISCARD_AND_EXPIRY
+
+	err := c.cmdQObj.Put(putmqmd, pmo, buf)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to put command to %s: %v", commandQueueName, err)
+	}
+
+	// Read responses until the last one in the set has been received
+	responses := []pcfResponse{}
+	for {
+		getmqmd := ibmmq.NewMQMD()
+		getmqmd.CorrelId = putmqmd.MsgId
+		gmo := ibmmq.NewMQGMO()
+		gmo.Options = ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_FAIL_IF_QUIESCING | ibmmq.MQGMO_WAIT | ibmmq.MQGMO_CONVERT
+		gmo.MatchOptions = ibmmq.MQMO_MATCH_CORREL_ID
+		gmo.WaitInterval = commandWait * 1000
+
+		datalen, err := c.replyQObj.Get(getmqmd, gmo, c.buffer)
+		if err != nil {
+			return responses, fmt.Errorf("Failed to get command response: %v", err)
+		}
+
+		response := parsePCFMessage(c.buffer[:datalen])
+		responses = append(responses, response)
+		if response.header.Control == ibmmq.MQCFC_LAST {
+			break
+		}
+	}
+
+	return responses, nil
+}
+
+// parsePCFMessage parses the header and all parameters from a PCF response message
+func parsePCFMessage(buf []byte) pcfResponse {
+	cfh, offset := ibmmq.ReadPCFHeader(buf)
+	response := pcfResponse{header: cfh}
+	for i := 0; i < int(cfh.ParameterCount) && offset < len(buf); i++ {
+		parameter, bytesRead := ibmmq.ReadPCFParameter(buf[offset:])
+		offset += bytesRead
+		response.parameters = append(response.parameters, parameter)
+	}
+	return response
+}
+
+// pcfString returns the bytes for a PCF string parameter
+func pcfString(parameter int32, value string) []byte {
+	return (&ibmmq.PCFParameter{Type: ibmmq.MQCFT_STRING, Parameter: parameter, String: []string{value}}).Bytes()
+}
+
+// pcfInteger returns the bytes for a PCF integer parameter
+func pcfInteger(parameter int32, value int32) []byte {
+	return (&ibmmq.PCFParameter{Type: ibmmq.MQCFT_INTEGER, Parameter: parameter, Int64Value: []int64{int64(value)}}).Bytes()
+}
+
+// pcfIntegerList returns the bytes for a PCF integer list parameter.  PCFParameter.Bytes only serialises integer
+// and string parameters, so the list is serialised here, in the same byte order.
+func pcfIntegerList(parameter int32, values []int32) []byte {
+	buf := make([]byte, int(ibmmq.MQCFIL_STRUC_LENGTH_FIXED)+4*len(values))
+	endian := nativeEndian()
+	endian.PutUint32(buf[0:], uint32(ibmmq.MQCFT_INTEGER_LIST))
+	endian.PutUint32(buf[4:], uint32(len(buf)))
+	endian.PutUint32(buf[8:], uint32(parameter))
+	endian.PutUint32(buf[12:], uint32(len(values)))
+	for i, value := range values {
+		endian.PutUint32(buf[int(ibmmq.MQCFIL_STRUC_LENGTH_FIXED)+4*i:], uint32(value))
+	}
+	return buf
+}
+
+// nativeEndian returns the byte order used by the queue manager for PCF messages, which PCFParameter.Bytes also uses
+func nativeEndian() binary.ByteOrder {
+	if ibmmq.MQENC_NATIVE%2 == 0 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
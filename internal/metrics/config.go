@@ -0,0 +1,116 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	queuesEnvVar      = "MQ_METRICS_QUEUES"
+	channelsEnvVar    = "MQ_METRICS_CHANNELS"
+	mappingFileEnvVar = "MQ_METRICS_MAPPING_FILE"
+)
+
+// metricsConfig holds the optional configuration for metrics gathering
+type metricsConfig struct {
+	queues      objectSelection
+	channels    objectSelection
+	metricNames map[string]metricLookup
+}
+
+// objectSelection holds the object name patterns selected for per-object metrics
+type objectSelection struct {
+	include []string
+	exclude []string
+}
+
+// getMetricsConfig returns the metrics configuration set in environment variables
+// - if an error is returned, the configuration which could not be processed is left at its default value
+func getMetricsConfig() (metricsConfig, error) {
+	var config metricsConfig
+	var err error
+
+	config.metricNames, err = loadMetricNamesMap(os.Getenv(mappingFileEnvVar))
+	if err != nil {
+		return config, err
+	}
+	config.queues, err = parseObjectSelection(queuesEnvVar, os.Getenv(queuesEnvVar))
+	if err != nil {
+		return config, err
+	}
+	config.channels, err = parseObjectSelection(channelsEnvVar, os.Getenv(channelsEnvVar))
+	if err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// commandsRequired returns true if a connection for sending commands to the command server is required
+func (c metricsConfig) commandsRequired() bool {
+	return c.queues.enabled() || c.channels.enabled()
+}
+
+// parseObjectSelection parses a comma-separated list of object name patterns
+// - patterns may end with an asterisk to match a generic name
+// - patterns starting with an exclamation mark exclude matching objects
+func parseObjectSelection(envVar, patterns string) (objectSelection, error) {
+	var selection objectSelection
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if pattern == "" || strings.Count(pattern, "*") > 1 || (strings.Contains(pattern, "*") && !strings.HasSuffix(pattern, "*")) {
+			return objectSelection{}, fmt.Errorf("Pattern '%s' in %s is not valid", pattern, envVar)
+		}
+		if exclude {
+			selection.exclude = append(selection.exclude, pattern)
+		} else {
+			selection.include = append(selection.include, pattern)
+		}
+	}
+	return selection, nil
+}
+
+// enabled returns true if any objects have been selected
+func (s objectSelection) enabled() bool {
+	return len(s.include) > 0
+}
+
+// excluded returns true if the object name matches any of the exclude patterns
+func (s objectSelection) excluded(name string) bool {
+	for _, pattern := range s.exclude {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern returns true if the object name matches a pattern using the MQ generic name rules
+func matchPattern(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == name
+}
@@ -0,0 +1,60 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+var channelSelectionTests = []struct {
+	patterns string
+	valid    bool
+	enabled  bool
+	name     string
+	excluded bool
+}{
+	{"", true, false, "APP.SVRCONN", false},
+	{"APP.SVRCONN", true, true, "APP.SVRCONN", false},
+	{"*, !SYSTEM.*", true, true, "SYSTEM.DEF.SVRCONN", true},
+	{"TO.*,!TO.QM3", true, true, "TO.QM2", false},
+	{"TO.*,!TO.QM3", true, true, "TO.QM3", true},
+	{"*.SVRCONN", false, false, "", false},
+}
+
+func TestParseChannelSelection(t *testing.T) {
+	for _, test := range channelSelectionTests {
+		selection, err := parseObjectSelection(channelsEnvVar, test.patterns)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error for patterns '%s': %v", test.patterns, err)
+			continue
+		}
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Expected error for patterns '%s'", test.patterns)
+			} else if !strings.Contains(err.Error(), channelsEnvVar) {
+				t.Errorf("Expected error to name %s; actual %v", channelsEnvVar, err)
+			}
+			continue
+		}
+		if selection.enabled() != test.enabled {
+			t.Errorf("Expected enabled=%v for patterns '%s'; actual %v", test.enabled, test.patterns, selection.enabled())
+		}
+		if selection.excluded(test.name) != test.excluded {
+			t.Errorf("Expected excluded=%v for channel %s with patterns '%s'; actual %v", test.excluded, test.name, test.patterns, selection.excluded(test.name))
+		}
+	}
+}
@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2019
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -18,6 +18,9 @@ limitations under the License.
 package metrics
 
 import (
+	"sync"
+	"time"
+
 	"github.com/ibm-messaging/mq-container/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -28,6 +31,10 @@ const (
 	qmgrLabel    = "qmgr"
 	objectPrefix = "object"
 	objectLabel  = "object"
+	// updateInterval is the minimum time between updates of the metric values.  A Prometheus request or an OTLP
+	// push within this interval of the last update is served the values from that update, so that they don't take
+	// publications of metric data from each other.
+	updateInterval = 10 * time.Second
 )
 
 type exporter struct {
@@ -35,7 +42,13 @@ type exporter struct {
 	gaugeMap     map[string]*prometheus.GaugeVec
 	counterMap   map[string]*prometheus.CounterVec
 	firstCollect bool
+	lastUpdate   time.Time
+	interval     time.Duration
 	log          *logger.Logger
+	// Collect may be called concurrently, for Prometheus requests and for pushing metrics
+	mutex sync.Mutex
+	// done is closed when metrics are no longer being processed
+	done chan struct{}
 }
 
 func newExporter(qmName string, log *logger.Logger) *exporter {
@@ -44,21 +57,45 @@ func newExporter(qmName string, log *logger.Logger) *exporter {
 		gaugeMap:     make(map[string]*prometheus.GaugeVec),
 		counterMap:   make(map[string]*prometheus.CounterVec),
 		firstCollect: true,
+		interval:     updateInterval,
 		log:          log,
+		done:         make(chan struct{}),
 	}
 }
 
+// stop indicates that metrics are no longer being processed, so that requests are not sent
+func (e *exporter) stop() {
+	close(e.done)
+}
+
 // Describe provides details of all available metrics
 func (e *exporter) Describe(ch chan<- *prometheus.Desc) {
 
-	requestChannel <- false
+	// Metrics which have already been described are described again without a request, for example
+	// when the exporter is unregistered after metrics are no longer being processed
+	if len(e.gaugeMap) > 0 || len(e.counterMap) > 0 {
+		for _, gaugeVec := range e.gaugeMap {
+			gaugeVec.Describe(ch)
+		}
+		for _, counterVec := range e.counterMap {
+			counterVec.Describe(ch)
+		}
+		return
+	}
+
+	select {
+	case requestChannel <- false:
+	case <-e.done:
+		return
+	}
 	response := <-responseChannel
 
 	for key, metric := range response {
 
 		if metric.isDelta {
 			// For delta type metrics - allocate a Prometheus Counter
-			counterVec := createCounterVec(metric.name, metric.description, metric.objectType)
+			prefix, labels := getMetricVecDetails(metric)
+			counterVec := newCounterVec(prefix, metric.name, metric.description, labels)
 			e.counterMap[key] = counterVec
 
 			// Describe metric
@@ -66,7 +103,8 @@ func (e *exporter) Describe(ch chan<- *prometheus.Desc) {
 
 		} else {
 			// For non-delta type metrics - allocate a Prometheus Gauge
-			gaugeVec := createGaugeVec(metric.name, metric.description, metric.objectType)
+			prefix, labels := getMetricVecDetails(metric)
+			gaugeVec := newGaugeVec(prefix, metric.name, metric.description, labels)
 			e.gaugeMap[key] = gaugeVec
 
 			// Describe metric
@@ -76,29 +114,54 @@ func (e *exporter) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect is called at regular intervals to provide the current metric data
+// - the metric values are updated at most once in each update interval, and are shared by all callers
 func (e *exporter) Collect(ch chan<- prometheus.Metric) {
 
-	requestChannel <- true
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.lastUpdate.IsZero() || time.Since(e.lastUpdate) >= e.interval {
+		if !e.update() {
+			return
+		}
+	}
+
+	// Collect metrics
+	for _, counterVec := range e.counterMap {
+		counterVec.Collect(ch)
+	}
+	for _, gaugeVec := range e.gaugeMap {
+		gaugeVec.Collect(ch)
+	}
+}
+
+// update requests the current metric data, and updates the Prometheus metrics with it
+// - false is returned if metrics are no longer being processed
+func (e *exporter) update() bool {
+
+	select {
+	case requestChannel <- true:
+	case <-e.done:
+		return false
+	}
 	response := <-responseChannel
+	e.lastUpdate = time.Now()
 
 	for key, metric := range response {
 
 		if metric.isDelta {
 			// For delta type metrics - update their Prometheus Counter
-			counterVec := e.counterMap[key]
+			// - metrics which have not been described yet are skipped
+			counterVec, ok := e.counterMap[key]
+			if !ok {
+				continue
+			}
 
 			// Populate Prometheus Counter with metric values
 			// - Skip on first collect to avoid build-up of accumulated values
 			if !e.firstCollect {
 				for label, value := range metric.values {
-					var err error
-					var counter prometheus.Counter
-
-					if label == qmgrLabelValue {
-						counter, err = counterVec.GetMetricWithLabelValues(e.qmName)
-					} else {
-						counter, err = counterVec.GetMetricWithLabelValues(label, e.qmName)
-					}
+					counter, err := counterVec.GetMetricWithLabelValues(e.getLabelValues(metric, label)...)
 					if err == nil {
 						counter.Add(value)
 					} else {
@@ -107,26 +170,20 @@ func (e *exporter) Collect(ch chan<- prometheus.Metric) {
 				}
 			}
 
-			// Collect metric
-			counterVec.Collect(ch)
-
 		} else {
 			// For non-delta type metrics - reset their Prometheus Gauge
-			gaugeVec := e.gaugeMap[key]
+			// - metrics which have not been described yet are skipped
+			gaugeVec, ok := e.gaugeMap[key]
+			if !ok {
+				continue
+			}
 			gaugeVec.Reset()
 
 			// Populate Prometheus Gauge with metric values
 			// - Skip on first collect to avoid build-up of accumulated values
 			if !e.firstCollect {
 				for label, value := range metric.values {
-					var err error
-					var gauge prometheus.Gauge
-
-					if label == qmgrLabelValue {
-						gauge, err = gaugeVec.GetMetricWithLabelValues(e.qmName)
-					} else {
-						gauge, err = gaugeVec.GetMetricWithLabelValues(label, e.qmName)
-					}
+					gauge, err := gaugeVec.GetMetricWithLabelValues(e.getLabelValues(metric, label)...)
 					if err == nil {
 						gauge.Set(value)
 					} else {
@@ -134,21 +191,34 @@ func (e *exporter) Collect(ch chan<- prometheus.Metric) {
 					}
 				}
 			}
-
-			// Collect metric
-			gaugeVec.Collect(ch)
 		}
 	}
 
 	if e.firstCollect {
 		e.firstCollect = false
 	}
+	return true
+}
+
+// getLabelValues returns the label values for a metric value
+func (e *exporter) getLabelValues(metric *metricData, label string) []string {
+	if labelValues, ok := metric.labelValues[label]; ok {
+		return append(append([]string{}, labelValues...), e.qmName)
+	}
+	if label == qmgrLabelValue {
+		return []string{e.qmName}
+	}
+	return []string{label, e.qmName}
 }
 
 // createCounterVec returns a Prometheus CounterVec populated with metric details
 func createCounterVec(name, description string, objectType bool) *prometheus.CounterVec {
-
 	prefix, labels := getVecDetails(objectType)
+	return newCounterVec(prefix, name, description, labels)
+}
+
+// newCounterVec returns a Prometheus CounterVec with the given prefix and labels
+func newCounterVec(prefix, name, description string, labels []string) *prometheus.CounterVec {
 
 	counterVec := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -163,8 +233,12 @@ func createCounterVec(name, description string, objectType bool) *prometheus.Cou
 
 // createGaugeVec returns a Prometheus GaugeVec populated with metric details
 func createGaugeVec(name, description string, objectType bool) *prometheus.GaugeVec {
-
 	prefix, labels := getVecDetails(objectType)
+	return newGaugeVec(prefix, name, description, labels)
+}
+
+// newGaugeVec returns a Prometheus GaugeVec with the given prefix and labels
+func newGaugeVec(prefix, name, description string, labels []string) *prometheus.GaugeVec {
 
 	gaugeVec := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -177,6 +251,14 @@ func createGaugeVec(name, description string, objectType bool) *prometheus.Gauge
 	return gaugeVec
 }
 
+// getMetricVecDetails returns the required prefix and labels for a metric, including any metric specific labels
+func getMetricVecDetails(metric *metricData) (prefix string, labels []string) {
+	if len(metric.labels) > 0 {
+		return metric.prefix, append(append([]string{}, metric.labels...), qmgrLabel)
+	}
+	return getVecDetails(metric.objectType)
+}
+
 // getVecDetails returns the required prefix and labels for a metric
 func getVecDetails(objectType bool) (prefix string, labels []string) {
 
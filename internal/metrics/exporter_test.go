@@ -16,6 +16,7 @@ limitations under the License.
 package metrics
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -53,7 +54,7 @@ func testDescribe(t *testing.T, isDelta bool) {
 	if isDelta {
 		mqmetric.Metrics.Classes[0].Types[0].Elements[0].Datatype = ibmmq.MQIAMO_MONITOR_DELTA
 	}
-	metrics, _ := initialiseMetrics(log)
+	metrics, _ := initialiseMetrics(log, generateMetricNamesMap())
 	responseChannel <- metrics
 
 	select {
@@ -83,6 +84,8 @@ func testCollect(t *testing.T, isDelta bool) {
 	log := getTestLogger()
 
 	exporter := newExporter("qmName", log)
+	// Update the metrics on every collect
+	exporter.interval = 0
 	if isDelta {
 		exporter.counterMap[testKey1] = createCounterVec(testElement1Name, testElement1Description, false)
 	} else {
@@ -106,7 +109,7 @@ func testCollect(t *testing.T, isDelta bool) {
 		if isDelta {
 			mqmetric.Metrics.Classes[0].Types[0].Elements[0].Datatype = ibmmq.MQIAMO_MONITOR_DELTA
 		}
-		metrics, _ := initialiseMetrics(log)
+		metrics, _ := initialiseMetrics(log, generateMetricNamesMap())
 		updateMetrics(metrics)
 		responseChannel <- metrics
 
@@ -139,6 +142,96 @@ func testCollect(t *testing.T, isDelta bool) {
 	}
 }
 
+func TestCollect_NotDescribed(t *testing.T) {
+
+	teardownTestCase := setupTestCase(false)
+	defer teardownTestCase()
+	log := getTestLogger()
+
+	exporter := newExporter("qmName", log)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		exporter.Collect(ch)
+		close(ch)
+	}()
+
+	collect := <-requestChannel
+	if !collect {
+		t.Errorf("Received unexpected describe request")
+	}
+
+	populateTestMetrics(1, false)
+	metrics, _ := initialiseMetrics(log, generateMetricNamesMap())
+	updateMetrics(metrics)
+	responseChannel <- metrics
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("Expected metrics which have not been described to be skipped")
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive channel response from collect")
+	}
+}
+
+func TestCollect_SharedUpdate(t *testing.T) {
+
+	teardownTestCase := setupTestCase(false)
+	defer teardownTestCase()
+	log := getTestLogger()
+
+	exporter := newExporter("qmName", log)
+	exporter.gaugeMap[testKey1] = createGaugeVec(testElement1Name, testElement1Description, false)
+	// The first collect does not populate values, so that accumulated values are not reported
+	exporter.firstCollect = false
+
+	collectTest := func(expectRequest bool) {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			exporter.Collect(ch)
+			close(ch)
+		}()
+
+		if expectRequest {
+			<-requestChannel
+			populateTestMetrics(1, false)
+			metrics, _ := initialiseMetrics(log, generateMetricNamesMap())
+			updateMetrics(metrics)
+			responseChannel <- metrics
+		}
+
+		collected := 0
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					if collected != 1 {
+						t.Errorf("Expected 1 metric to be collected; actual %d", collected)
+					}
+					return
+				}
+				collected++
+			case <-requestChannel:
+				t.Fatal("Expected collect within the update interval to use the values from the last update")
+			case <-time.After(1 * time.Second):
+				t.Fatal("Did not receive channel response from collect")
+			}
+		}
+	}
+
+	// A Prometheus request followed by an OTLP push both see the values from the same update
+	collectTest(true)
+	collectTest(false)
+
+	prometheusMetric := dto.Metric{}
+	exporter.gaugeMap[testKey1].WithLabelValues("qmName").Write(&prometheusMetric)
+	if prometheusMetric.GetGauge().GetValue() != float64(1) {
+		t.Errorf("Expected value=%f; actual %f", float64(1), prometheusMetric.GetGauge().GetValue())
+	}
+}
+
 func TestCreateCounterVec(t *testing.T) {
 
 	ch := make(chan *prometheus.Desc)
@@ -202,3 +295,22 @@ func TestCreateGaugeVec_ObjectLabel(t *testing.T) {
 		t.Errorf("Expected value=%s; actual %s", expected, actual)
 	}
 }
+
+func TestGetMetricVecDetails_Labels(t *testing.T) {
+
+	metric := &metricData{
+		name:   "status",
+		prefix: channelPrefix,
+		labels: []string{channelLabel, channelTypeLabel, connectionLabel},
+	}
+	prefix, labels := getMetricVecDetails(metric)
+
+	if prefix != channelPrefix {
+		t.Errorf("Expected prefix=%s; actual %s", channelPrefix, prefix)
+	}
+	expected := "[channel type connection_name qmgr]"
+	actual := fmt.Sprintf("%v", labels)
+	if actual != expected {
+		t.Errorf("Expected labels=%s; actual %s", expected, actual)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/containerruntime"
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	instancePrefix = "instance"
+	roleLabel      = "role"
+	pathLabel      = "path"
+)
+
+// instanceRoles are all of the roles reported by the role metric
+var instanceRoles = []string{ready.RoleActive, ready.RoleStandby, ready.RoleReplica, ready.RoleUnknown}
+
+// instanceVolumes are the container volumes for which filesystem usage is reported, if they exist
+var instanceVolumes = []string{"/mnt/mqm", "/mnt/mqm-log", "/mnt/mqm-data"}
+
+// instanceCollector provides metrics about this instance of the queue manager, which are available
+// whether or not the queue manager is active
+type instanceCollector struct {
+	qmName    string
+	startTime time.Time
+	volumes   []string
+	usage     func(path string) (containerruntime.FilesystemUsage, error)
+	log       *logger.Logger
+
+	mutex sync.Mutex
+	role  string
+
+	roleDesc      *prometheus.Desc
+	uptimeDesc    *prometheus.Desc
+	fsSizeDesc    *prometheus.Desc
+	fsFreeDesc    *prometheus.Desc
+	fsAvailDesc   *prometheus.Desc
+	fsInUseDesc   *prometheus.Desc
+	fsUnavailable map[string]bool
+}
+
+func newInstanceCollector(qmName string, log *logger.Logger) *instanceCollector {
+	return &instanceCollector{
+		qmName:        qmName,
+		startTime:     time.Now(),
+		volumes:       instanceVolumes,
+		usage:         containerruntime.GetFilesystemUsage,
+		log:           log,
+		role:          ready.RoleUnknown,
+		roleDesc:      newInstanceDesc("role", "Role of this instance of the queue manager (1 for the current role)", roleLabel),
+		uptimeDesc:    newInstanceDesc("uptime_seconds", "Time since metrics gathering started for this instance"),
+		fsSizeDesc:    newInstanceDesc("filesystem_size_bytes", "Size of the file system containing the volume", pathLabel),
+		fsFreeDesc:    newInstanceDesc("filesystem_free_bytes", "Free space in the file system containing the volume", pathLabel),
+		fsAvailDesc:   newInstanceDesc("filesystem_available_bytes", "Space available to the queue manager in the file system containing the volume", pathLabel),
+		fsInUseDesc:   newInstanceDesc("filesystem_in_use_bytes", "Space in use in the file system containing the volume", pathLabel),
+		fsUnavailable: make(map[string]bool),
+	}
+}
+
+// newInstanceDesc returns the description of an instance metric
+func newInstanceDesc(name, description string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, instancePrefix, name),
+		description,
+		append(labels, qmgrLabel),
+		nil,
+	)
+}
+
+// setRole sets the current role of this instance
+func (c *instanceCollector) setRole(role string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.role = role
+}
+
+// getRole returns the current role of this instance
+func (c *instanceCollector) getRole() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.role
+}
+
+// Describe provides details of all instance metrics
+func (c *instanceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.roleDesc
+	ch <- c.uptimeDesc
+	ch <- c.fsSizeDesc
+	ch <- c.fsFreeDesc
+	ch <- c.fsAvailDesc
+	ch <- c.fsInUseDesc
+}
+
+// Collect provides the current values of all instance metrics
+func (c *instanceCollector) Collect(ch chan<- prometheus.Metric) {
+
+	role := c.getRole()
+	for _, r := range instanceRoles {
+		value := 0.0
+		if r == role {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.roleDesc, prometheus.GaugeValue, value, r, c.qmName)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, time.Since(c.startTime).Seconds(), c.qmName)
+
+	for _, volume := range c.volumes {
+		// Volumes are optional, for example a separate log volume is only used in some configurations
+		if _, err := os.Stat(volume); err != nil {
+			continue
+		}
+		usage, err := c.usage(volume)
+		if err != nil {
+			c.mutex.Lock()
+			if !c.fsUnavailable[volume] {
+				c.fsUnavailable[volume] = true
+				c.log.Errorf("Metrics Error: Failed to get file system usage for %s: %v", volume, err)
+			}
+			c.mutex.Unlock()
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.fsSizeDesc, prometheus.GaugeValue, float64(usage.Size), volume, c.qmName)
+		ch <- prometheus.MustNewConstMetric(c.fsFreeDesc, prometheus.GaugeValue, float64(usage.Free), volume, c.qmName)
+		ch <- prometheus.MustNewConstMetric(c.fsAvailDesc, prometheus.GaugeValue, float64(usage.Available), volume, c.qmName)
+		ch <- prometheus.MustNewConstMetric(c.fsInUseDesc, prometheus.GaugeValue, float64(usage.Size-usage.Free), volume, c.qmName)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/containerruntime"
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestInstanceCollector(t *testing.T) {
+
+	volume := t.TempDir()
+	collector := newInstanceCollector("QM1", getTestLogger())
+	collector.volumes = []string{volume, volume + "/missing"}
+	collector.usage = func(path string) (containerruntime.FilesystemUsage, error) {
+		return containerruntime.FilesystemUsage{Size: 100, Free: 40, Available: 30}, nil
+	}
+	collector.setRole(ready.RoleReplica)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	values := make(map[string][]*dto.Metric)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()
+	}
+
+	roles := values["ibmmq_instance_role"]
+	if len(roles) != len(instanceRoles) {
+		t.Fatalf("Expected %d role values; actual %d", len(instanceRoles), len(roles))
+	}
+	for _, metric := range roles {
+		expected := 0.0
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == roleLabel && label.GetValue() == ready.RoleReplica {
+				expected = 1
+			}
+		}
+		if metric.GetGauge().GetValue() != expected {
+			t.Errorf("Expected role value=%f for %v; actual %f", expected, metric.GetLabel(), metric.GetGauge().GetValue())
+		}
+	}
+
+	if len(values["ibmmq_instance_uptime_seconds"]) != 1 {
+		t.Error("Expected uptime metric")
+	}
+
+	inUse := values["ibmmq_instance_filesystem_in_use_bytes"]
+	if len(inUse) != 1 {
+		t.Fatalf("Expected file system usage for 1 volume; actual %d", len(inUse))
+	}
+	if inUse[0].GetGauge().GetValue() != 60 {
+		t.Errorf("Expected in use bytes=%f; actual %f", float64(60), inUse[0].GetGauge().GetValue())
+	}
+}
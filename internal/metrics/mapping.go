@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -17,6 +17,36 @@ limitations under the License.
 // Package metrics contains code to provide metrics for the queue manager
 package metrics
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMappingFile is the location of the optional metric mapping file
+const defaultMappingFile = "/etc/mqm/metrics-mapping.yaml"
+
+// metricNameRegexp matches valid Prometheus metric names
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// metricMappingFile describes the format of the metric mapping file, where metrics are identified
+// by their class/type/description key
+type metricMappingFile struct {
+	Metrics map[string]metricMapping `yaml:"metrics"`
+}
+
+// metricMapping holds the overrides for a single metric - unset fields keep their default values
+type metricMapping struct {
+	Name    string `yaml:"name"`
+	Enabled *bool  `yaml:"enabled"`
+}
+
 type metricLookup struct {
 	name    string
 	enabled bool
@@ -119,6 +149,132 @@ func generateMetricNamesMap() map[string]metricLookup {
 		"STATMQI/GET/Failed MQCB count":                                           metricLookup{"failed_mqcb_total", true},
 		"STATMQI/SYNCPOINT/Commit count":                                          metricLookup{"commit_total", true},
 		"STATMQI/SYNCPOINT/Rollback count":                                        metricLookup{"rollback_total", true},
+		"STATQ/OPENCLOSE/MQOPEN count":                                            metricLookup{"mqopen_total", true},
+		"STATQ/OPENCLOSE/MQCLOSE count":                                           metricLookup{"mqclose_total", true},
+		"STATQ/INQSET/MQINQ count":                                                metricLookup{"mqinq_total", true},
+		"STATQ/INQSET/MQSET count":                                                metricLookup{"mqset_total", true},
+		"STATQ/PUT/MQPUT/MQPUT1 count":                                            metricLookup{"mqput_mqput1_total", true},
+		"STATQ/PUT/MQPUT byte count":                                              metricLookup{"mqput_bytes_total", true},
+		"STATQ/PUT/MQPUT non-persistent message count":                            metricLookup{"non_persistent_message_mqput_total", true},
+		"STATQ/PUT/MQPUT persistent message count":                                metricLookup{"persistent_message_mqput_total", true},
+		"STATQ/PUT/rolled back MQPUT count":                                       metricLookup{"rolled_back_mqput_total", true},
+		"STATQ/PUT/MQPUT1 non-persistent message count":                           metricLookup{"non_persistent_message_mqput1_total", true},
+		"STATQ/PUT/MQPUT1 persistent message count":                               metricLookup{"persistent_message_mqput1_total", true},
+		"STATQ/PUT/non-persistent byte count":                                     metricLookup{"non_persistent_message_put_bytes_total", true},
+		"STATQ/PUT/persistent byte count":                                         metricLookup{"persistent_message_put_bytes_total", true},
+		"STATQ/PUT/lock contention":                                               metricLookup{"lock_contention_percentage", true},
+		"STATQ/PUT/queue avoided puts":                                            metricLookup{"queue_avoided_puts_percentage", true},
+		"STATQ/PUT/queue avoided bytes":                                           metricLookup{"queue_avoided_bytes_percentage", true},
+		"STATQ/GET/MQGET count":                                                   metricLookup{"mqget_total", true},
+		"STATQ/GET/MQGET byte count":                                              metricLookup{"mqget_bytes_total", true},
+		"STATQ/GET/destructive MQGET non-persistent message count":                metricLookup{"non_persistent_message_destructive_get_total", true},
+		"STATQ/GET/destructive MQGET persistent message count":                    metricLookup{"persistent_message_destructive_get_total", true},
+		"STATQ/GET/destructive MQGET non-persistent byte count":                   metricLookup{"non_persistent_message_destructive_get_bytes_total", true},
+		"STATQ/GET/destructive MQGET persistent byte count":                       metricLookup{"persistent_message_destructive_get_bytes_total", true},
+		"STATQ/GET/MQGET browse non-persistent message count":                     metricLookup{"non_persistent_message_browse_total", true},
+		"STATQ/GET/MQGET browse persistent message count":                         metricLookup{"persistent_message_browse_total", true},
+		"STATQ/GET/MQGET browse non-persistent byte count":                        metricLookup{"non_persistent_message_browse_bytes_total", true},
+		"STATQ/GET/MQGET browse persistent byte count":                            metricLookup{"persistent_message_browse_bytes_total", true},
+		"STATQ/GET/destructive MQGET fails":                                       metricLookup{"failed_mqget_total", true},
+		"STATQ/GET/destructive MQGET fails with MQRC_NO_MSG_AVAILABLE":            metricLookup{"failed_mqget_no_message_available_total", true},
+		"STATQ/GET/destructive MQGET fails with MQRC_TRUNCATED_MSG_FAILED":        metricLookup{"failed_mqget_truncated_message_total", true},
+		"STATQ/GET/MQGET browse fails":                                            metricLookup{"failed_browse_total", true},
+		"STATQ/GET/MQGET browse fails with MQRC_NO_MSG_AVAILABLE":                 metricLookup{"failed_browse_no_message_available_total", true},
+		"STATQ/GET/MQGET browse fails with MQRC_TRUNCATED_MSG_FAILED":             metricLookup{"failed_browse_truncated_message_total", true},
+		"STATQ/GET/rolled back MQGET count":                                       metricLookup{"rolled_back_mqget_total", true},
+		"STATQ/GENERAL/messages expired":                                          metricLookup{"expired_message_total", true},
+		"STATQ/GENERAL/queue purged count":                                        metricLookup{"purged_queue_total", true},
+		"STATQ/GENERAL/average queue time":                                        metricLookup{"average_queue_time_seconds", true},
+		"STATQ/GENERAL/Queue depth":                                               metricLookup{"queue_depth", true},
 	}
 	return metricNamesMap
 }
+
+// loadMetricNamesMap returns the default metric names, updated with any overrides from a mapping file
+// - if the file is not set, the default mapping file is used if it exists
+func loadMetricNamesMap(file string) (map[string]metricLookup, error) {
+
+	metricNamesMap := generateMetricNamesMap()
+
+	required := file != ""
+	if !required {
+		file = defaultMappingFile
+	}
+
+	// #nosec G304 - the file name is a defined constant, or is set by the administrator of the container
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return metricNamesMap, nil
+		}
+		return metricNamesMap, fmt.Errorf("Failed to read metric mapping file %s: %v", file, err)
+	}
+
+	overrides, err := parseMetricMappings(buf)
+	if err != nil {
+		return generateMetricNamesMap(), fmt.Errorf("Failed to parse metric mapping file %s: %v", file, err)
+	}
+	for key, override := range overrides {
+		metricNamesMap[key] = override.apply(metricNamesMap[key], key)
+	}
+	return metricNamesMap, nil
+}
+
+// parseMetricMappings parses and validates the contents of a metric mapping file
+func parseMetricMappings(buf []byte) (map[string]metricMapping, error) {
+
+	var mappingFile metricMappingFile
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&mappingFile)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for key, mapping := range mappingFile.Metrics {
+		if strings.Count(key, "/") < 2 {
+			return nil, fmt.Errorf("Metric key '%s' must be in the format class/type/description", key)
+		}
+		if mapping.Name != "" && !metricNameRegexp.MatchString(mapping.Name) {
+			return nil, fmt.Errorf("Metric name '%s' for key '%s' is not valid", mapping.Name, key)
+		}
+	}
+	return mappingFile.Metrics, nil
+}
+
+// apply returns the metric lookup updated with the overrides from a mapping
+// - metrics which are not in the default mapping are added, and are enabled unless specified otherwise
+func (m metricMapping) apply(lookup metricLookup, key string) metricLookup {
+	if lookup.name == "" {
+		lookup = metricLookup{generateMetricName(key, false), true}
+	}
+	if m.Name != "" {
+		lookup.name = m.Name
+	}
+	if m.Enabled != nil {
+		lookup.enabled = *m.Enabled
+	}
+	return lookup
+}
+
+// generateMetricName generates a metric name from the class/type/description key of a metric which has
+// no defined mapping, for example a metric added in a newer level of MQ
+func generateMetricName(key string, isDelta bool) string {
+	var name strings.Builder
+	separator := false
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if separator && name.Len() > 0 {
+				name.WriteRune('_')
+			}
+			name.WriteRune(r)
+			separator = false
+		} else {
+			separator = true
+		}
+	}
+	if isDelta && !strings.HasSuffix(name.String(), "_total") {
+		name.WriteString("_total")
+	}
+	return name.String()
+}
@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -21,8 +21,8 @@ func TestGenerateMetricNamesMap(t *testing.T) {
 
 	metricNamesMap := generateMetricNamesMap()
 
-	if len(metricNamesMap) != 93 {
-		t.Errorf("Expected mapping-size=%d; actual %d", 93, len(metricNamesMap))
+	if len(metricNamesMap) != 130 {
+		t.Errorf("Expected mapping-size=%d; actual %d", 130, len(metricNamesMap))
 	}
 
 	actual, ok := metricNamesMap[testKey1]
@@ -35,3 +35,62 @@ func TestGenerateMetricNamesMap(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMetricMappings(t *testing.T) {
+
+	mappingFile := `
+metrics:
+  "CPU/SystemSummary/RAM total bytes":
+    enabled: true
+  "CPU/SystemSummary/CPU load - five minute average":
+    name: cpu_load_5m
+    enabled: false
+  "STATMQI/NEWTYPE/New element count": {}
+`
+	overrides, err := parseMetricMappings([]byte(mappingFile))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	metricNamesMap := generateMetricNamesMap()
+	for key, override := range overrides {
+		metricNamesMap[key] = override.apply(metricNamesMap[key], key)
+	}
+
+	ram := metricNamesMap["CPU/SystemSummary/RAM total bytes"]
+	if ram.name != "system_ram_size_bytes" || !ram.enabled {
+		t.Errorf("Expected system_ram_size_bytes to be enabled; actual %+v", ram)
+	}
+	load := metricNamesMap[testKey1]
+	if load.name != "cpu_load_5m" || load.enabled {
+		t.Errorf("Expected cpu_load_5m to be disabled; actual %+v", load)
+	}
+	added := metricNamesMap["STATMQI/NEWTYPE/New element count"]
+	if added.name != "statmqi_newtype_new_element_count" || !added.enabled {
+		t.Errorf("Expected statmqi_newtype_new_element_count to be enabled; actual %+v", added)
+	}
+}
+
+func TestParseMetricMappings_Invalid(t *testing.T) {
+
+	invalidFiles := []string{
+		"metrics:\n  \"CPU/SystemSummary/RAM total bytes\":\n    name: ram-bytes\n",
+		"metrics:\n  \"RAM total bytes\":\n    enabled: true\n",
+		"metrics:\n  \"CPU/SystemSummary/RAM total bytes\":\n    enable: true\n",
+	}
+	for _, mappingFile := range invalidFiles {
+		_, err := parseMetricMappings([]byte(mappingFile))
+		if err == nil {
+			t.Errorf("Expected error for mapping file %q", mappingFile)
+		}
+	}
+}
+
+func TestGenerateMetricName(t *testing.T) {
+
+	expected := "statq_get_destructive_mqget_fails_with_mqrc_no_msg_available_total"
+	actual := generateMetricName("STATQ/GET/destructive MQGET fails with MQRC_NO_MSG_AVAILABLE", true)
+	if actual != expected {
+		t.Errorf("Expected name=%s; actual %s", expected, actual)
+	}
+}
@@ -21,8 +21,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
+	"github.com/ibm-messaging/mq-container/internal/health"
 	"github.com/ibm-messaging/mq-container/internal/ready"
 	"github.com/ibm-messaging/mq-container/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
@@ -34,35 +36,32 @@ const (
 )
 
 var (
-	metricsEnabled = false
-	// #nosec G112 - this code is changing soon to use https.
-	// for now we will ignore the gosec.
-	metricsServer  = &http.Server{Addr: ":" + defaultPort}
+	metricsEnabled  = false
+	otlpEnabled     = false
+	metricsServer   = &http.Server{Addr: ":" + defaultPort, ReadHeaderTimeout: 10 * time.Second}
+	roleStopChannel = make(chan bool, 1)
 )
 
 // GatherMetrics gathers metrics for the queue manager
-func GatherMetrics(qmName string, log *logger.Logger) {
-
-	// If running in standby mode - wait until the queue manager becomes active
-	for {
-		status, _ := ready.Status(context.Background(), qmName)
-		if status.ActiveQM() {
-			break
-		}
-		time.Sleep(requestTimeout * time.Second)
-	}
+// - the metrics server is started on every instance, and resource statistics are gathered while the instance is active
+// - the key label is used to serve metrics over TLS with the default key, if MQ_METRICS_TLS is enabled
+func GatherMetrics(qmName string, keyLabel string, log *logger.Logger) {
 
 	metricsEnabled = true
+	log = log.With("component", "metrics")
 
-	err := startMetricsGathering(qmName, log)
+	err := startMetricsGathering(qmName, keyLabel, log)
 	if err != nil {
 		log.Errorf("Metrics Error: %s", err.Error())
 		StopMetricsGathering(log)
+		health.SetComponentState(health.ComponentMetrics, health.StateFailed, err)
+		return
 	}
+	health.SetComponentState(health.ComponentMetrics, health.StateRunning, nil)
 }
 
 // startMetricsGathering starts gathering metrics for the queue manager
-func startMetricsGathering(qmName string, log *logger.Logger) error {
+func startMetricsGathering(qmName string, keyLabel string, log *logger.Logger) error {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -72,45 +71,153 @@ func startMetricsGathering(qmName string, log *logger.Logger) error {
 
 	log.Println("Starting metrics gathering")
 
-	// Start processing metrics
-	go processMetrics(log, qmName)
+	// Configure TLS and authentication before gathering starts, so that metrics are never served insecurely
+	mux := http.NewServeMux()
+	config, err := configureServer(metricsServer, mux, keyLabel)
+	if err != nil {
+		return err
+	}
 
-	// Wait for metrics to be ready before starting the Prometheus handler
-	<-startChannel
+	// Register metrics for this instance, which are available whether or not the queue manager is active
+	instance := newInstanceCollector(qmName, log)
+	err = prometheus.Register(instance)
+	if err != nil {
+		return fmt.Errorf("Failed to register metrics: %v", err)
+	}
 
-	// Register metrics
-	metricsExporter := newExporter(qmName, log)
-	err := prometheus.Register(metricsExporter)
+	// Register metrics for the expiry of the certificates found by runmqserver
+	err = prometheus.Register(newCertificateCollector(qmName))
 	if err != nil {
 		return fmt.Errorf("Failed to register metrics: %v", err)
 	}
 
+	// Register metrics for the native HA status saved by runmqserver
+	if os.Getenv("MQ_NATIVE_HA") == "true" {
+		err = prometheus.Register(newNativeHACollector(qmName, log))
+		if err != nil {
+			return fmt.Errorf("Failed to register metrics: %v", err)
+		}
+	}
+
+	// Push metrics to an OTLP collector (if configured), alongside the Prometheus endpoint
+	otlp, err := getOTLPConfig()
+	if err != nil {
+		log.Errorf("Metrics Error: %s", err.Error())
+	} else if otlp.endpoint != "" {
+		otlpEnabled = true
+		go newOTLPExporter(otlp, qmName, prometheus.DefaultGatherer, instance.getRole, log).run()
+	}
+
 	// Setup HTTP server to handle requests from Prometheus
-	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
 		// #nosec G104
 		w.Write([]byte("Status: METRICS ACTIVE"))
 	})
 
 	go func() {
-		err = metricsServer.ListenAndServe()
+		var err error
+		if config.tlsEnabled {
+			err = metricsServer.ListenAndServeTLS("", "")
+		} else {
+			err = metricsServer.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			log.Errorf("Metrics Error: Failed to handle metrics request: %v", err)
 			StopMetricsGathering(log)
+			health.SetComponentState(health.ComponentMetrics, health.StateFailed, err)
 		}
 	}()
 
+	// Gather resource statistics while this instance is active
+	go watchInstanceRole(qmName, instance, log)
+
 	return nil
 }
 
+// watchInstanceRole checks the role of this instance at regular intervals, and starts gathering resource
+// statistics when the instance becomes active, or stops gathering them when it is no longer active
+func watchInstanceRole(qmName string, instance *instanceCollector, log *logger.Logger) {
+
+	var metricsExporter *exporter
+	gathering := false
+
+	stopGathering := func() {
+		if metricsExporter != nil {
+			// Stop the exporter first, so that Describe does not wait for a response while it is unregistered
+			metricsExporter.stop()
+			prometheus.Unregister(metricsExporter)
+			metricsExporter = nil
+		}
+		if gathering {
+			stopChannel <- true
+			<-stoppedChannel
+			gathering = false
+		}
+	}
+
+	for {
+		role := getInstanceRole(qmName)
+		if role != instance.getRole() {
+			log.Printf("Metrics: Queue manager instance role is %s", role)
+			instance.setRole(role)
+		}
+
+		if role == ready.RoleActive && !gathering {
+			go processMetrics(log, qmName)
+			gathering = true
+		}
+
+		if role == ready.RoleActive && metricsExporter == nil {
+			// Wait for metrics to be ready before registering them, checking the role again after the timeout
+			select {
+			case <-startChannel:
+				metricsExporter = newExporter(qmName, log)
+				err := prometheus.Register(metricsExporter)
+				if err != nil {
+					log.Errorf("Metrics Error: Failed to register metrics: %v", err)
+				}
+			case <-time.After(requestTimeout * time.Second):
+				continue
+			case <-roleStopChannel:
+				stopGathering()
+				return
+			}
+		}
+
+		if role != ready.RoleActive && gathering {
+			log.Println("Stopping resource statistics gathering, as the queue manager is not active")
+			stopGathering()
+		}
+
+		select {
+		case <-time.After(requestTimeout * time.Second):
+		case <-roleStopChannel:
+			stopGathering()
+			return
+		}
+	}
+}
+
 // StopMetricsGathering stops gathering metrics for the queue manager
 func StopMetricsGathering(log *logger.Logger) {
 
 	if metricsEnabled {
 
 		// Stop processing metrics
-		stopChannel <- true
+		select {
+		case roleStopChannel <- true:
+		default:
+		}
+
+		// Stop pushing metrics
+		if otlpEnabled {
+			select {
+			case otlpStopChannel <- true:
+			default:
+			}
+		}
 
 		// Shutdown HTTP server
 		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -119,5 +226,15 @@ func StopMetricsGathering(log *logger.Logger) {
 		if err != nil {
 			log.Errorf("Failed to shutdown metrics server: %v", err)
 		}
+		health.SetComponentState(health.ComponentMetrics, health.StateStopped, nil)
+	}
+}
+
+// getInstanceRole returns the role of this instance of the queue manager: active, standby, replica or unknown
+func getInstanceRole(qmName string) string {
+	status, err := ready.Status(context.Background(), qmName)
+	if err != nil {
+		return ready.RoleUnknown
 	}
+	return status.Role()
 }
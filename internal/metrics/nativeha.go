@@ -0,0 +1,122 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	nativeHAPrefix = "nativeha"
+	instanceLabel  = "instance"
+)
+
+// nativeHACollector provides metrics from the native HA status saved by runmqserver
+type nativeHACollector struct {
+	qmName string
+	status func() (*ready.NativeHAStatus, error)
+	log    *logger.Logger
+
+	mutex        sync.Mutex
+	statusFailed bool
+
+	inSyncDesc       *prometheus.Desc
+	ageDesc          *prometheus.Desc
+	roleDesc         *prometheus.Desc
+	connectedDesc    *prometheus.Desc
+	instanceSyncDesc *prometheus.Desc
+	backlogDesc      *prometheus.Desc
+}
+
+func newNativeHACollector(qmName string, log *logger.Logger) *nativeHACollector {
+	return &nativeHACollector{
+		qmName:           qmName,
+		status:           ready.ReadNativeHAStatus,
+		log:              log,
+		inSyncDesc:       newNativeHADesc("in_sync", "Whether this instance is in sync with the active instance (1 if in sync)"),
+		ageDesc:          newNativeHADesc("status_age_seconds", "Time since the native HA status was last updated"),
+		roleDesc:         newNativeHADesc("instance_role", "Role of each native HA instance (1 for the current role)", instanceLabel, roleLabel),
+		connectedDesc:    newNativeHADesc("instance_connected", "Whether the replication connection to each native HA instance is active (1 if connected)", instanceLabel),
+		instanceSyncDesc: newNativeHADesc("instance_in_sync", "Whether each native HA instance is in sync (1 if in sync)", instanceLabel),
+		backlogDesc:      newNativeHADesc("instance_backlog_bytes", "Log data not yet replicated to each native HA instance", instanceLabel),
+	}
+}
+
+// newNativeHADesc returns the description of a native HA metric
+func newNativeHADesc(name, description string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, nativeHAPrefix, name),
+		description,
+		append(labels, qmgrLabel),
+		nil,
+	)
+}
+
+// Describe provides details of all native HA metrics
+func (c *nativeHACollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.inSyncDesc
+	ch <- c.ageDesc
+	ch <- c.roleDesc
+	ch <- c.connectedDesc
+	ch <- c.instanceSyncDesc
+	ch <- c.backlogDesc
+}
+
+// Collect provides the values of all native HA metrics from the last saved status
+func (c *nativeHACollector) Collect(ch chan<- prometheus.Metric) {
+
+	status, err := c.status()
+	c.mutex.Lock()
+	if err != nil && !c.statusFailed {
+		c.log.Errorf("Metrics Error: Failed to read native HA status: %v", err)
+	}
+	c.statusFailed = err != nil
+	c.mutex.Unlock()
+	if err != nil || status == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.inSyncDesc, prometheus.GaugeValue, boolValue(status.InSync), c.qmName)
+	ch <- prometheus.MustNewConstMetric(c.ageDesc, prometheus.GaugeValue, time.Since(status.Updated).Seconds(), c.qmName)
+
+	for _, instance := range status.Instances {
+		role := strings.ToLower(instance.Role)
+		if role == "" {
+			role = ready.RoleUnknown
+		}
+		ch <- prometheus.MustNewConstMetric(c.roleDesc, prometheus.GaugeValue, 1, instance.Name, role, c.qmName)
+		ch <- prometheus.MustNewConstMetric(c.connectedDesc, prometheus.GaugeValue, boolValue(instance.Connected), instance.Name, c.qmName)
+		ch <- prometheus.MustNewConstMetric(c.instanceSyncDesc, prometheus.GaugeValue, boolValue(instance.InSync), instance.Name, c.qmName)
+		if instance.Backlog >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.backlogDesc, prometheus.GaugeValue, float64(instance.Backlog), instance.Name, c.qmName)
+		}
+	}
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
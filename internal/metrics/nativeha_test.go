@@ -0,0 +1,101 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/ready"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNativeHACollector(t *testing.T) {
+
+	collector := newNativeHACollector("QM1", getTestLogger())
+	collector.status = func() (*ready.NativeHAStatus, error) {
+		return &ready.NativeHAStatus{
+			QueueManager: "QM1",
+			Instance:     "inst1",
+			Role:         "Active",
+			InSync:       true,
+			Updated:      time.Now(),
+			Instances: []ready.NativeHAInstanceStatus{
+				{Name: "inst1", Role: "Active", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst2", Role: "Replica", Connected: true, InSync: false, Backlog: 4096},
+				{Name: "inst3", Role: "Replica", Connected: false, InSync: false, Backlog: -1},
+			},
+		}, nil
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	values := make(map[string]map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			instance := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == instanceLabel {
+					instance = label.GetValue()
+				}
+			}
+			values[family.GetName()][instance] = metric.GetGauge().GetValue()
+		}
+	}
+
+	if values["ibmmq_nativeha_in_sync"][""] != 1 {
+		t.Error("Expected this instance to be in sync")
+	}
+	if values["ibmmq_nativeha_instance_connected"]["inst3"] != 0 {
+		t.Error("Expected inst3 to be disconnected")
+	}
+	if values["ibmmq_nativeha_instance_in_sync"]["inst2"] != 0 {
+		t.Error("Expected inst2 to be out of sync")
+	}
+	if values["ibmmq_nativeha_instance_backlog_bytes"]["inst2"] != 4096 {
+		t.Errorf("Expected inst2 backlog=%d; actual %f", 4096, values["ibmmq_nativeha_instance_backlog_bytes"]["inst2"])
+	}
+	if _, ok := values["ibmmq_nativeha_instance_backlog_bytes"]["inst3"]; ok {
+		t.Error("Expected no backlog metric for inst3, as the backlog is unknown")
+	}
+	if len(values["ibmmq_nativeha_instance_role"]) != 3 {
+		t.Errorf("Expected role for 3 instances; actual %d", len(values["ibmmq_nativeha_instance_role"]))
+	}
+}
+
+func TestNativeHACollector_NoStatus(t *testing.T) {
+
+	collector := newNativeHACollector("QM1", getTestLogger())
+	collector.status = func() (*ready.NativeHAStatus, error) {
+		return nil, nil
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if len(families) != 0 {
+		t.Errorf("Expected no metrics; actual %d", len(families))
+	}
+}
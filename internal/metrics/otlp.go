@@ -0,0 +1,307 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	otlpMetricsPath      = "/v1/metrics"
+	otlpIntervalDefault  = 60
+	otlpTimeout          = 10
+	otlpScopeName        = "github.com/ibm-messaging/mq-container/internal/metrics"
+	otlpServiceName      = "ibm-mq"
+	otlpCumulative       = 2
+	otlpQueueManagerAttr = "ibmmq.queue_manager"
+	otlpRoleAttr         = "ibmmq.ha.role"
+)
+
+var otlpStopChannel = make(chan bool, 1)
+
+// otlpConfig holds the configuration for pushing metrics to an OTLP collector
+type otlpConfig struct {
+	endpoint string
+	interval time.Duration
+	headers  map[string]string
+}
+
+// otlpExporter pushes the metrics from a Prometheus gatherer to an OTLP collector using OTLP/HTTP with JSON encoding
+type otlpExporter struct {
+	config    otlpConfig
+	qmName    string
+	hostname  string
+	startTime time.Time
+	gatherer  prometheus.Gatherer
+	role      func() string
+	client    *http.Client
+	log       *logger.Logger
+}
+
+// OTLP/HTTP JSON request structures - only the fields used for queue manager metrics are included
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// getOTLPConfig returns the OTLP configuration set in environment variables
+// - the endpoint is empty if pushing metrics is not enabled
+func getOTLPConfig() (otlpConfig, error) {
+	config := otlpConfig{interval: otlpIntervalDefault * time.Second}
+
+	endpoint := strings.TrimSpace(os.Getenv("MQ_METRICS_OTLP_ENDPOINT"))
+	if endpoint == "" {
+		return config, nil
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+		return otlpConfig{}, fmt.Errorf("MQ_METRICS_OTLP_ENDPOINT must be an http or https URL: %s", endpoint)
+	}
+	if endpointURL.Path == "" || endpointURL.Path == "/" {
+		endpointURL.Path = otlpMetricsPath
+	}
+	config.endpoint = endpointURL.String()
+
+	interval := os.Getenv("MQ_METRICS_OTLP_INTERVAL")
+	if interval != "" {
+		seconds, err := strconv.Atoi(interval)
+		if err != nil || seconds <= 0 {
+			return otlpConfig{}, fmt.Errorf("MQ_METRICS_OTLP_INTERVAL must be a positive number of seconds: %s", interval)
+		}
+		config.interval = time.Duration(seconds) * time.Second
+	}
+
+	// Headers are specified in the same format as OTEL_EXPORTER_OTLP_HEADERS, for example to supply credentials
+	config.headers = make(map[string]string)
+	for _, header := range strings.Split(os.Getenv("MQ_METRICS_OTLP_HEADERS"), ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return otlpConfig{}, fmt.Errorf("MQ_METRICS_OTLP_HEADERS must be a comma-separated list of key=value pairs")
+		}
+		value, err = url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return otlpConfig{}, fmt.Errorf("Failed to decode value of header %s in MQ_METRICS_OTLP_HEADERS: %v", key, err)
+		}
+		config.headers[strings.TrimSpace(key)] = value
+	}
+
+	return config, nil
+}
+
+// newOTLPExporter returns an exporter which pushes metrics from the gatherer
+func newOTLPExporter(config otlpConfig, qmName string, gatherer prometheus.Gatherer, role func() string, log *logger.Logger) *otlpExporter {
+	// #nosec G104 - the host name attribute is left empty if it is not available
+	hostname, _ := os.Hostname()
+	return &otlpExporter{
+		config:    config,
+		qmName:    qmName,
+		hostname:  hostname,
+		startTime: time.Now(),
+		gatherer:  gatherer,
+		role:      role,
+		client:    &http.Client{Timeout: otlpTimeout * time.Second},
+		log:       log,
+	}
+}
+
+// run pushes metrics at the configured interval, until a stop request is received
+func (e *otlpExporter) run() {
+	e.log.Printf("Pushing metrics to %s every %v", e.config.endpoint, e.config.interval)
+	ticker := time.NewTicker(e.config.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := e.push()
+			if err != nil {
+				e.log.Errorf("Metrics Error: Failed to push metrics to %s: %v", e.config.endpoint, err)
+			}
+		case <-otlpStopChannel:
+			return
+		}
+	}
+}
+
+// push gathers the current metrics and sends them to the OTLP collector
+// - resource statistics are shared with Prometheus requests, so gathering them here does not take their data
+func (e *otlpExporter) push() error {
+
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("Failed to gather metrics: %v", err)
+	}
+
+	body, err := json.Marshal(e.buildRequest(families, time.Now()))
+	if err != nil {
+		return fmt.Errorf("Failed to encode metrics: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range e.config.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// #nosec G104 - the response body is read so that the connection can be reused
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Collector returned status %s", response.Status)
+	}
+	return nil
+}
+
+// buildRequest converts the queue manager metric families to an OTLP export request
+// - gauges are sent as OTLP gauges, and counters as cumulative monotonic sums
+func (e *otlpExporter) buildRequest(families []*dto.MetricFamily, now time.Time) otlpRequest {
+
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+	startTimestamp := strconv.FormatInt(e.startTime.UnixNano(), 10)
+
+	metrics := []otlpMetric{}
+	for _, family := range families {
+		// Only queue manager metrics are sent, and not metrics about the runmqserver process
+		if !strings.HasPrefix(family.GetName(), namespace+"_") {
+			continue
+		}
+		metric := otlpMetric{Name: family.GetName(), Description: family.GetHelp()}
+		dataPoints := []otlpDataPoint{}
+		for _, m := range family.GetMetric() {
+			dataPoint := otlpDataPoint{TimeUnixNano: timestamp}
+			for _, label := range m.GetLabel() {
+				dataPoint.Attributes = append(dataPoint.Attributes, newOTLPAttribute(label.GetName(), label.GetValue()))
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				dataPoint.StartTimeUnixNano = startTimestamp
+				dataPoint.AsDouble = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				dataPoint.AsDouble = m.GetGauge().GetValue()
+			default:
+				continue
+			}
+			dataPoints = append(dataPoints, dataPoint)
+		}
+		if family.GetType() == dto.MetricType_COUNTER {
+			metric.Sum = &otlpSum{DataPoints: dataPoints, AggregationTemporality: otlpCumulative, IsMonotonic: true}
+		} else {
+			metric.Gauge = &otlpGauge{DataPoints: dataPoints}
+		}
+		metrics = append(metrics, metric)
+	}
+
+	attributes := []otlpAttribute{
+		newOTLPAttribute("service.name", otlpServiceName),
+		newOTLPAttribute(otlpQueueManagerAttr, e.qmName),
+		newOTLPAttribute("host.name", e.hostname),
+	}
+	if e.role != nil {
+		attributes = append(attributes, newOTLPAttribute(otlpRoleAttr, e.role()))
+	}
+
+	return otlpRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{Attributes: attributes},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: otlpScopeName},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+// newOTLPAttribute returns an OTLP attribute with a string value
+func newOTLPAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: value}}
+}
@@ -0,0 +1,134 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGetOTLPConfig(t *testing.T) {
+	t.Setenv("MQ_METRICS_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("MQ_METRICS_OTLP_INTERVAL", "15")
+	t.Setenv("MQ_METRICS_OTLP_HEADERS", "Authorization=Bearer%20token,X-Tenant=mq")
+
+	config, err := getOTLPConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if config.endpoint != "http://collector:4318/v1/metrics" {
+		t.Errorf("Expected endpoint=%s; actual %s", "http://collector:4318/v1/metrics", config.endpoint)
+	}
+	if config.interval != 15*time.Second {
+		t.Errorf("Expected interval=%v; actual %v", 15*time.Second, config.interval)
+	}
+	if config.headers["Authorization"] != "Bearer token" || config.headers["X-Tenant"] != "mq" {
+		t.Errorf("Unexpected headers %v", config.headers)
+	}
+}
+
+func TestGetOTLPConfig_Invalid(t *testing.T) {
+	t.Setenv("MQ_METRICS_OTLP_ENDPOINT", "collector:4318")
+
+	_, err := getOTLPConfig()
+	if err == nil {
+		t.Error("Expected error for endpoint without a scheme")
+	}
+}
+
+func TestOTLPPush(t *testing.T) {
+
+	// Stand-in for an OTLP collector, which records the last request
+	var received otlpRequest
+	var contentType string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		err := json.Unmarshal(body, &received)
+		if err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	registry := prometheus.NewRegistry()
+	gaugeVec := createGaugeVec("queue_depth", "Queue depth", true)
+	counterVec := createCounterVec("mqput_total", "MQPUT count", false)
+	registry.MustRegister(gaugeVec, counterVec)
+	gaugeVec.WithLabelValues("APP.Q1", "QM1").Set(5)
+	counterVec.WithLabelValues("QM1").Add(3)
+
+	config := otlpConfig{endpoint: collector.URL + otlpMetricsPath, interval: time.Second}
+	exporter := newOTLPExporter(config, "QM1", registry, func() string { return "active" }, getTestLogger())
+	err := exporter.push()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Expected content type=%s; actual %s", "application/json", contentType)
+	}
+	if len(received.ResourceMetrics) != 1 {
+		t.Fatalf("Expected 1 resource; actual %d", len(received.ResourceMetrics))
+	}
+	attributes := make(map[string]string)
+	for _, attribute := range received.ResourceMetrics[0].Resource.Attributes {
+		attributes[attribute.Key] = attribute.Value.StringValue
+	}
+	if attributes[otlpQueueManagerAttr] != "QM1" || attributes[otlpRoleAttr] != "active" || attributes["host.name"] == "" {
+		t.Errorf("Unexpected resource attributes %v", attributes)
+	}
+
+	metrics := received.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("Expected 2 metrics; actual %d", len(metrics))
+	}
+	for _, metric := range metrics {
+		switch metric.Name {
+		case "ibmmq_object_queue_depth":
+			if metric.Gauge == nil || metric.Gauge.DataPoints[0].AsDouble != 5 || len(metric.Gauge.DataPoints[0].Attributes) != 2 {
+				t.Errorf("Unexpected gauge %+v", metric)
+			}
+		case "ibmmq_qmgr_mqput_total":
+			if metric.Sum == nil || !metric.Sum.IsMonotonic || metric.Sum.AggregationTemporality != otlpCumulative || metric.Sum.DataPoints[0].AsDouble != 3 {
+				t.Errorf("Unexpected sum %+v", metric)
+			}
+		default:
+			t.Errorf("Unexpected metric %s", metric.Name)
+		}
+	}
+}
+
+func TestOTLPPush_CollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	config := otlpConfig{endpoint: collector.URL + otlpMetricsPath, interval: time.Second}
+	exporter := newOTLPExporter(config, "QM1", prometheus.NewRegistry(), nil, getTestLogger())
+	err := exporter.push()
+	if err == nil {
+		t.Error("Expected error when collector returns an error status")
+	}
+}
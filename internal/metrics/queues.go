@@ -0,0 +1,148 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+	"github.com/ibm-messaging/mq-golang/ibmmq"
+)
+
+const (
+	queueClassName   = "STATQ"
+	queueStatusClass = "QSTATUS"
+)
+
+// queueStatusMetric describes a metric obtained from the queue status, rather than from a publication
+type queueStatusMetric struct {
+	parameter   int32
+	description string
+	name        string
+}
+
+// queueStatusMetrics are the per-queue metrics obtained using the 'Inquire Queue Status' command
+var queueStatusMetrics = []queueStatusMetric{
+	{ibmmq.MQIACF_OLDEST_MSG_AGE, "Oldest message age", "oldest_message_age_seconds"},
+	{ibmmq.MQIACF_UNCOMMITTED_MSGS, "Uncommitted messages", "uncommitted_messages"},
+	{ibmmq.MQIA_OPEN_INPUT_COUNT, "Open input handles", "open_input_handles"},
+	{ibmmq.MQIA_OPEN_OUTPUT_COUNT, "Open output handles", "open_output_handles"},
+}
+
+// discoverQueues returns the names of all local queues which match the queue selection
+func discoverQueues(conn *commandConnection, selection objectSelection, log *logger.Logger) ([]string, error) {
+	found := make(map[string]bool)
+
+	for _, pattern := range selection.include {
+		responses, err := conn.sendCommand(ibmmq.MQCMD_INQUIRE_Q_NAMES, [][]byte{
+			pcfString(ibmmq.MQCA_Q_NAME, pattern),
+			pcfInteger(ibmmq.MQIA_Q_TYPE, ibmmq.MQQT_LOCAL),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, response := range responses {
+			if response.header.CompCode != ibmmq.MQCC_OK {
+				log.With("queuePattern", pattern, "errorCode", response.header.Reason).Errorf("Metrics Error: Failed to discover queues matching '%s': reason %d", pattern, response.header.Reason)
+				continue
+			}
+			for _, parameter := range response.parameters {
+				if parameter.Parameter == ibmmq.MQCACF_Q_NAMES {
+					for _, queue := range parameter.String {
+						found[strings.TrimSpace(queue)] = true
+					}
+				}
+			}
+		}
+	}
+
+	queues := []string{}
+	for queue := range found {
+		if queue != "" && !selection.excluded(queue) {
+			queues = append(queues, queue)
+		}
+	}
+	sort.Strings(queues)
+	if len(queues) == 0 {
+		log.Printf("Metrics: No queues found matching %s", queuesEnvVar)
+	}
+	return queues, nil
+}
+
+// initialiseQueueStatusMetrics adds the metrics obtained from the queue status
+func initialiseQueueStatusMetrics(metrics map[string]*metricData) {
+	for _, statusMetric := range queueStatusMetrics {
+		metrics[makeQueueStatusKey(statusMetric)] = &metricData{
+			name:        statusMetric.name,
+			description: statusMetric.description,
+			objectType:  true,
+			values:      make(map[string]float64),
+		}
+	}
+}
+
+// updateQueueStatusMetrics updates the metrics obtained from the queue status of each selected queue
+func updateQueueStatusMetrics(metrics map[string]*metricData, conn *commandConnection, queues []string) error {
+	attrs := make([]int32, 0, len(queueStatusMetrics))
+	for _, statusMetric := range queueStatusMetrics {
+		attrs = append(attrs, statusMetric.parameter)
+		if metric, ok := metrics[makeQueueStatusKey(statusMetric)]; ok {
+			metric.values = make(map[string]float64)
+		}
+	}
+
+	for _, queue := range queues {
+		responses, err := conn.sendCommand(ibmmq.MQCMD_INQUIRE_Q_STATUS, [][]byte{
+			pcfString(ibmmq.MQCA_Q_NAME, queue),
+			pcfIntegerList(ibmmq.MQIACF_Q_STATUS_ATTRS, attrs),
+		})
+		if err != nil {
+			return err
+		}
+		for _, response := range responses {
+			if response.header.CompCode != ibmmq.MQCC_OK {
+				// The queue may have been deleted since it was discovered
+				continue
+			}
+			setQueueStatusValues(metrics, queue, response.parameters)
+		}
+	}
+	return nil
+}
+
+// setQueueStatusValues sets the metric values for a queue, from the parameters of a queue status response
+func setQueueStatusValues(metrics map[string]*metricData, queue string, parameters []*ibmmq.PCFParameter) {
+	for _, statusMetric := range queueStatusMetrics {
+		metric, ok := metrics[makeQueueStatusKey(statusMetric)]
+		if !ok {
+			continue
+		}
+		for _, parameter := range parameters {
+			// Negative values indicate that the attribute is not available, for example when MONQ is disabled
+			if parameter.Parameter == statusMetric.parameter && len(parameter.Int64Value) > 0 && parameter.Int64Value[0] >= 0 {
+				metric.values[queue] = float64(parameter.Int64Value[0])
+			}
+		}
+	}
+}
+
+// makeQueueStatusKey builds a unique key for each queue status metric
+func makeQueueStatusKey(statusMetric queueStatusMetric) string {
+	return queueStatusClass + "/" + statusMetric.description
+}
@@ -0,0 +1,145 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang/ibmmq"
+	"github.com/ibm-messaging/mq-golang/mqmetric"
+)
+
+var queueSelectionTests = []struct {
+	patterns string
+	valid    bool
+	enabled  bool
+	queue    string
+	excluded bool
+}{
+	{"", true, false, "APP.Q1", false},
+	{"APP.*", true, true, "APP.Q1", false},
+	{"APP.*, !APP.INTERNAL.*", true, true, "APP.INTERNAL.Q1", true},
+	{"*,!SYSTEM.*", true, true, "SYSTEM.DEFAULT.LOCAL.QUEUE", true},
+	{"!SYSTEM.*", true, false, "APP.Q1", false},
+	{"APP.*.Q1", false, false, "", false},
+	{"APP**", false, false, "", false},
+	{"!", false, false, "", false},
+}
+
+func TestParseQueueSelection(t *testing.T) {
+	for _, test := range queueSelectionTests {
+		selection, err := parseObjectSelection(queuesEnvVar, test.patterns)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error for patterns '%s': %v", test.patterns, err)
+			continue
+		}
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Expected error for patterns '%s'", test.patterns)
+			}
+			continue
+		}
+		if selection.enabled() != test.enabled {
+			t.Errorf("Expected enabled=%v for patterns '%s'; actual %v", test.enabled, test.patterns, selection.enabled())
+		}
+		if selection.excluded(test.queue) != test.excluded {
+			t.Errorf("Expected excluded=%v for queue %s with patterns '%s'; actual %v", test.excluded, test.queue, test.patterns, selection.excluded(test.queue))
+		}
+	}
+}
+
+func TestInitialiseMetrics_QueueMetrics(t *testing.T) {
+
+	teardownTestCase := setupTestCase(false)
+	defer teardownTestCase()
+
+	queueType := mqmetric.Metrics.Classes[0].Types[1]
+	queueType.Parent.Name = queueClassName
+	queueType.Name = "GET"
+	queueType.Elements[0].Parent = queueType
+	queueType.Elements[0].Description = "MQGET count"
+	queueType.Elements[0].Values["APP.Q1"] = 3
+
+	// Queue manager metrics in the same class are not expected, so create a new class for them
+	qmgrClass := new(mqmetric.MonClass)
+	qmgrClass.Name = testClassName
+	qmgrClass.Types = map[int]*mqmetric.MonType{0: mqmetric.Metrics.Classes[0].Types[0]}
+	qmgrClass.Types[0].Parent = qmgrClass
+	delete(mqmetric.Metrics.Classes[0].Types, 0)
+	mqmetric.Metrics.Classes[1] = qmgrClass
+
+	metrics, err := initialiseMetrics(getTestLogger(), generateMetricNamesMap())
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	metric, ok := metrics["STATQ/GET/MQGET count"]
+	if !ok {
+		t.Fatal("Expected queue metric not found in map")
+	}
+	if !metric.objectType {
+		t.Errorf("Expected objectType=%v; actual %v", true, metric.objectType)
+	}
+
+	updateMetrics(metrics)
+	if metric.values["APP.Q1"] != 3 {
+		t.Errorf("Expected metric value=%f for queue APP.Q1; actual %f", float64(3), metric.values["APP.Q1"])
+	}
+}
+
+func TestSetQueueStatusValues(t *testing.T) {
+	metrics := make(map[string]*metricData)
+	initialiseQueueStatusMetrics(metrics)
+
+	if len(metrics) != len(queueStatusMetrics) {
+		t.Fatalf("Expected %d queue status metrics; actual %d", len(queueStatusMetrics), len(metrics))
+	}
+
+	parameters := []*ibmmq.PCFParameter{
+		{Type: ibmmq.MQCFT_STRING, Parameter: ibmmq.MQCA_Q_NAME, String: []string{"APP.Q1"}},
+		{Type: ibmmq.MQCFT_INTEGER, Parameter: ibmmq.MQIACF_OLDEST_MSG_AGE, Int64Value: []int64{-1}},
+		{Type: ibmmq.MQCFT_INTEGER, Parameter: ibmmq.MQIACF_UNCOMMITTED_MSGS, Int64Value: []int64{4}},
+	}
+	setQueueStatusValues(metrics, "APP.Q1", parameters)
+
+	oldest := metrics[makeQueueStatusKey(queueStatusMetrics[0])]
+	if _, ok := oldest.values["APP.Q1"]; ok {
+		t.Error("Unexpected value for oldest message age, when the value is not available")
+	}
+	uncommitted := metrics[makeQueueStatusKey(queueStatusMetrics[1])]
+	if uncommitted.values["APP.Q1"] != 4 {
+		t.Errorf("Expected uncommitted messages=%f; actual %f", float64(4), uncommitted.values["APP.Q1"])
+	}
+}
+
+func TestPCFIntegerList(t *testing.T) {
+	buf := pcfIntegerList(ibmmq.MQIACF_Q_STATUS_ATTRS, []int32{ibmmq.MQIACF_OLDEST_MSG_AGE, ibmmq.MQIACF_UNCOMMITTED_MSGS})
+	if len(buf) != int(ibmmq.MQCFIL_STRUC_LENGTH_FIXED)+8 {
+		t.Errorf("Expected PCF integer list length=%d; actual %d", ibmmq.MQCFIL_STRUC_LENGTH_FIXED+8, len(buf))
+	}
+	endian := nativeEndian()
+	if int32(endian.Uint32(buf[0:])) != ibmmq.MQCFT_INTEGER_LIST {
+		t.Errorf("Expected type=%d; actual %d", ibmmq.MQCFT_INTEGER_LIST, endian.Uint32(buf[0:]))
+	}
+	if int32(endian.Uint32(buf[4:])) != int32(len(buf)) {
+		t.Errorf("Expected structure length=%d; actual %d", len(buf), endian.Uint32(buf[4:]))
+	}
+	if int32(endian.Uint32(buf[12:])) != 2 {
+		t.Errorf("Expected count=2; actual %d", endian.Uint32(buf[12:]))
+	}
+	if int32(endian.Uint32(buf[20:])) != ibmmq.MQIACF_UNCOMMITTED_MSGS {
+		t.Errorf("Expected second value=%d; actual %d", ibmmq.MQIACF_UNCOMMITTED_MSGS, endian.Uint32(buf[20:]))
+	}
+}
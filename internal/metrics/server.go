@@ -0,0 +1,149 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains code to provide metrics for the queue manager
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/tls"
+)
+
+// serverConfig holds the security configuration for the metrics server
+type serverConfig struct {
+	tlsEnabled    bool
+	clientAuth    bool
+	bearerToken   string
+	basicUser     string
+	basicPassword string
+}
+
+// getServerConfig returns the metrics server configuration set in environment variables
+func getServerConfig() (serverConfig, error) {
+	var config serverConfig
+
+	config.tlsEnabled = isEnvTrue("MQ_METRICS_TLS")
+	config.clientAuth = isEnvTrue("MQ_METRICS_TLS_CLIENT_AUTH")
+	if config.clientAuth && !config.tlsEnabled {
+		return serverConfig{}, fmt.Errorf("MQ_METRICS_TLS_CLIENT_AUTH requires MQ_METRICS_TLS to be enabled")
+	}
+
+	tokenFile := os.Getenv("MQ_METRICS_BEARER_TOKEN_FILE")
+	if tokenFile != "" {
+		token, err := readSecretFile(tokenFile)
+		if err != nil {
+			return serverConfig{}, err
+		}
+		config.bearerToken = token
+	}
+
+	basicAuthFile := os.Getenv("MQ_METRICS_BASIC_AUTH_FILE")
+	if basicAuthFile != "" {
+		credentials, err := readSecretFile(basicAuthFile)
+		if err != nil {
+			return serverConfig{}, err
+		}
+		user, password, ok := strings.Cut(credentials, ":")
+		if !ok || user == "" || password == "" {
+			return serverConfig{}, fmt.Errorf("File %s must contain credentials in the format username:password", basicAuthFile)
+		}
+		config.basicUser = user
+		config.basicPassword = password
+	}
+
+	return config, nil
+}
+
+// configureServer configures TLS and authentication for the metrics server
+func configureServer(server *http.Server, handler http.Handler, keyLabel string) (serverConfig, error) {
+
+	config, err := getServerConfig()
+	if err != nil {
+		return config, err
+	}
+
+	if config.tlsEnabled {
+		server.TLSConfig, err = tls.ConfigureMetricsTLS(keyLabel, config.clientAuth)
+		if err != nil {
+			return config, fmt.Errorf("Failed to configure TLS for metrics: %v", err)
+		}
+	}
+	server.Handler = authHandler(handler, config)
+
+	return config, nil
+}
+
+// authHandler returns a handler which requires a valid bearer token or basic authentication credentials, if either is configured
+func authHandler(handler http.Handler, config serverConfig) http.Handler {
+
+	if config.bearerToken == "" && config.basicUser == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.bearerToken != "" {
+			authorization := r.Header.Get("Authorization")
+			if strings.HasPrefix(authorization, "Bearer ") && secureCompare(strings.TrimPrefix(authorization, "Bearer "), config.bearerToken) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		if config.basicUser != "" {
+			user, password, ok := r.BasicAuth()
+			// Both values are always compared, so that the time taken does not depend on which is incorrect
+			userMatch := secureCompare(user, config.basicUser)
+			passwordMatch := secureCompare(password, config.basicPassword)
+			if ok && userMatch && passwordMatch {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+// secureCompare compares two strings in constant time
+func secureCompare(actual, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+
+// readSecretFile returns the trimmed contents of a file containing a secret
+func readSecretFile(file string) (string, error) {
+	// #nosec G304 - the file name is set by the administrator of the container
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read file %s: %v", file, err)
+	}
+	secret := strings.TrimSpace(string(buf))
+	if secret == "" {
+		return "", fmt.Errorf("File %s is empty", file)
+	}
+	return secret, nil
+}
+
+// isEnvTrue returns true if an environment variable is set to 'true' or '1'
+func isEnvTrue(name string) bool {
+	value := os.Getenv(name)
+	return value == "true" || value == "1"
+}
@@ -0,0 +1,89 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var authHandlerTests = []struct {
+	config   serverConfig
+	token    string
+	user     string
+	password string
+	expected int
+}{
+	{serverConfig{}, "", "", "", http.StatusOK},
+	{serverConfig{bearerToken: "secret"}, "secret", "", "", http.StatusOK},
+	{serverConfig{bearerToken: "secret"}, "wrong", "", "", http.StatusUnauthorized},
+	{serverConfig{bearerToken: "secret"}, "", "", "", http.StatusUnauthorized},
+	{serverConfig{basicUser: "prometheus", basicPassword: "passw0rd"}, "", "prometheus", "passw0rd", http.StatusOK},
+	{serverConfig{basicUser: "prometheus", basicPassword: "passw0rd"}, "", "prometheus", "wrong", http.StatusUnauthorized},
+	{serverConfig{bearerToken: "secret", basicUser: "prometheus", basicPassword: "passw0rd"}, "", "prometheus", "passw0rd", http.StatusOK},
+	{serverConfig{bearerToken: "secret", basicUser: "prometheus", basicPassword: "passw0rd"}, "secret", "", "", http.StatusOK},
+}
+
+func TestAuthHandler(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for i, test := range authHandlerTests {
+		request := httptest.NewRequest("GET", "/metrics", nil)
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		if test.user != "" {
+			request.SetBasicAuth(test.user, test.password)
+		}
+		recorder := httptest.NewRecorder()
+		authHandler(handler, test.config).ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expected {
+			t.Errorf("Test %d: expected status=%d; actual %d", i, test.expected, recorder.Code)
+		}
+	}
+}
+
+func TestGetServerConfig_BasicAuth(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+	err := os.WriteFile(file, []byte("prometheus:pass:w0rd\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MQ_METRICS_BASIC_AUTH_FILE", file)
+
+	config, err := getServerConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if config.basicUser != "prometheus" || config.basicPassword != "pass:w0rd" {
+		t.Errorf("Expected credentials prometheus/pass:w0rd; actual %s/%s", config.basicUser, config.basicPassword)
+	}
+}
+
+func TestGetServerConfig_ClientAuthWithoutTLS(t *testing.T) {
+	t.Setenv("MQ_METRICS_TLS_CLIENT_AUTH", "true")
+
+	_, err := getServerConfig()
+	if err == nil {
+		t.Error("Expected error when client authentication is enabled without TLS")
+	}
+}
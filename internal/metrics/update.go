@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2019
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -35,6 +35,7 @@ const (
 var (
 	startChannel    = make(chan bool)
 	stopChannel     = make(chan bool, 2)
+	stoppedChannel  = make(chan bool, 1)
 	requestChannel  = make(chan bool)
 	responseChannel = make(chan map[string]*metricData)
 )
@@ -45,25 +46,53 @@ type metricData struct {
 	objectType  bool
 	values      map[string]float64
 	isDelta     bool
+	prefix      string
+	labels      []string
+	labelValues map[string][]string
 }
 
 // processMetrics processes publications of metric data and handles describe/collect/stop requests
 func processMetrics(log *logger.Logger, qmName string) {
 
+	// Signal when processing has stopped, so that processing can be safely restarted
+	defer func() {
+		stoppedChannel <- true
+	}()
+
 	var err error
 	var firstConnect = true
 	var metrics map[string]*metricData
+	var conn *commandConnection
+	var queues []string
+
+	config, err := getMetricsConfig()
+	if err != nil {
+		log.Errorf("Metrics Error: %s", err.Error())
+	}
 
 	for {
 		// Connect to queue manager and discover available metrics
-		err = doConnect(qmName)
+		conn, queues, err = doConnect(qmName, config, log)
 		if err == nil {
 			if firstConnect {
 				firstConnect = false
-				startChannel <- true
+				select {
+				case startChannel <- true:
+				case <-stopChannel:
+					log.Println("Stopping metrics gathering")
+					mqmetric.EndConnection()
+					conn.close()
+					return
+				}
 			}
 			// #nosec G104
-			metrics, _ = initialiseMetrics(log)
+			metrics, _ = initialiseMetrics(log, config.metricNames)
+			if config.queues.enabled() {
+				initialiseQueueStatusMetrics(metrics)
+			}
+			if config.channels.enabled() {
+				initialiseChannelStatusMetrics(metrics)
+			}
 		}
 
 		// Now loop until something goes wrong
@@ -79,11 +108,24 @@ func processMetrics(log *logger.Logger, qmName string) {
 				case collect := <-requestChannel:
 					if collect {
 						updateMetrics(metrics)
+						if conn != nil {
+							statusErr := updateQueueStatusMetrics(metrics, conn, queues)
+							if statusErr != nil {
+								log.Errorf("Metrics Error: Failed to update queue status metrics: %v", statusErr)
+							}
+						}
+						if conn != nil && config.channels.enabled() {
+							statusErr := updateChannelStatusMetrics(metrics, conn, config.channels)
+							if statusErr != nil {
+								log.Errorf("Metrics Error: Failed to update channel status metrics: %v", statusErr)
+							}
+						}
 					}
 					responseChannel <- metrics
 				case <-stopChannel:
 					log.Println("Stopping metrics gathering")
 					mqmetric.EndConnection()
+					conn.close()
 					return
 				case <-time.After(requestTimeout * time.Second):
 					log.Debugf("Metrics: No requests received within timeout period (%d seconds)", requestTimeout)
@@ -94,6 +136,7 @@ func processMetrics(log *logger.Logger, qmName string) {
 
 		// Close the connection
 		mqmetric.EndConnection()
+		conn.close()
 
 		// Handle stop requests
 		select {
@@ -107,7 +150,8 @@ func processMetrics(log *logger.Logger, qmName string) {
 }
 
 // doConnect connects to the queue manager and discovers available metrics
-func doConnect(qmName string) error {
+// - if any queues or channels have been selected, a connection for sending commands is also returned, with the list of matching queues
+func doConnect(qmName string, config metricsConfig, log *logger.Logger) (*commandConnection, []string, error) {
 
 	// Set connection configuration
 	var connConfig mqmetric.ConnectionConfig
@@ -118,65 +162,102 @@ func doConnect(qmName string) error {
 	// Connect to the queue manager - open the command and dynamic reply queues
 	err := mqmetric.InitConnectionStats(qmName, "SYSTEM.DEFAULT.MODEL.QUEUE", "", &connConfig)
 	if err != nil {
-		return fmt.Errorf("Failed to connect to queue manager %s: %v", qmName, err)
+		return nil, nil, fmt.Errorf("Failed to connect to queue manager %s: %v", qmName, err)
+	}
+
+	// Open a connection for sending commands, which is used for discovery and for obtaining status
+	var conn *commandConnection
+	var queues []string
+	if config.commandsRequired() {
+		conn, err = newCommandConnection(qmName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Discover the selected queues - these are passed as an explicit list, so that subscriptions are
+	// not duplicated when we reconnect
+	if config.queues.enabled() {
+		queues, err = discoverQueues(conn, config.queues, log)
+		if err != nil {
+			conn.close()
+			return nil, nil, fmt.Errorf("Failed to discover queues for metrics: %v", err)
+		}
 	}
 
 	// Discover available metrics for the queue manager and subscribe to them
-	err = mqmetric.DiscoverAndSubscribe("", true, "")
+	// - a list of queues is passed explicitly, because it replaces the selection from a previous connection.  An
+	//   empty list can't be passed that way, as it selects a single blank queue name, so no queues are discovered
+	//   instead, which keeps the previous selection.  That is empty unless queues were found for a previous
+	//   connection, and have since been deleted, in which case no statistics are published for them.
+	if len(queues) > 0 {
+		err = mqmetric.DiscoverAndSubscribe(strings.Join(queues, ","), false, "")
+	} else {
+		err = mqmetric.DiscoverAndSubscribe("", true, "")
+	}
 	if err != nil {
-		return fmt.Errorf("Failed to discover and subscribe to metrics: %v", err)
+		conn.close()
+		return nil, nil, fmt.Errorf("Failed to discover and subscribe to metrics: %v", err)
 	}
 
-	return nil
+	return conn, queues, nil
 }
 
 // initialiseMetrics sets initial details for all available metrics
-func initialiseMetrics(log *logger.Logger) (map[string]*metricData, error) {
+// - metrics with no defined mapping are given a name generated from their key
+func initialiseMetrics(log *logger.Logger, metricNamesMap map[string]metricLookup) (map[string]*metricData, error) {
 
 	metrics := make(map[string]*metricData)
+	metricNames := make(map[string]string)
 	validMetrics := true
-	metricNamesMap := generateMetricNamesMap()
 
 	for _, metricClass := range mqmetric.Metrics.Classes {
 		for _, metricType := range metricClass.Types {
-			if !strings.Contains(metricType.ObjectTopic, "%s") {
+			if isSupportedType(metricType) {
 				for _, metricElement := range metricType.Elements {
 
 					// Get unique metric key
 					key := makeKey(metricElement)
 
-					// Get metric name from mapping
-					if metricLookup, found := metricNamesMap[key]; found {
+					// Check if metric is a delta type
+					isDelta := false
+					if metricElement.Datatype == ibmmq.MQIAMO_MONITOR_DELTA {
+						isDelta = true
+					}
 
-						// Check if metric is enabled
-						if metricLookup.enabled {
+					// Get metric name from mapping, or generate a name for unexpected metrics
+					metricLookup, found := metricNamesMap[key]
+					if !found {
+						metricLookup.name = generateMetricName(key, isDelta)
+						metricLookup.enabled = true
+						log.Printf("Metrics: Using generated name %s for metric with no defined mapping [%s]", metricLookup.name, key)
+					}
 
-							// Check if metric is a delta type
-							isDelta := false
-							if metricElement.Datatype == ibmmq.MQIAMO_MONITOR_DELTA {
-								isDelta = true
-							}
+					// Check if metric is enabled
+					if metricLookup.enabled {
 
-							// Set metric details
-							metric := metricData{
-								name:        metricLookup.name,
-								description: metricElement.Description,
-								isDelta:     isDelta,
-							}
+						// Set metric details
+						metric := metricData{
+							name:        metricLookup.name,
+							description: metricElement.Description,
+							objectType:  isObjectType(metricType),
+							isDelta:     isDelta,
+						}
 
-							// Add metric
-							if _, exists := metrics[key]; !exists {
-								metrics[key] = &metric
-							} else {
-								log.Errorf("Metrics Error: Found duplicate metric key [%s]", key)
-								validMetrics = false
-							}
+						// Add metric - names must be unique for queue manager metrics, and for object metrics
+						prefix, _ := getVecDetails(metric.objectType)
+						if _, exists := metrics[key]; exists {
+							log.Errorf("Metrics Error: Found duplicate metric key [%s]", key)
+							validMetrics = false
+						} else if existingKey, exists := metricNames[prefix+"_"+metric.name]; exists {
+							log.Errorf("Metrics Error: Skipping metric, name %s for key [%s] is already used for key [%s]", metric.name, key, existingKey)
+							validMetrics = false
 						} else {
-							log.Debugf("Metrics: Skipping metric, metric is not enabled for key [%s]", key)
+							metrics[key] = &metric
+							metricNames[prefix+"_"+metric.name] = key
 						}
 					} else {
-						log.Errorf("Metrics Error: Skipping metric, unexpected key [%s]", key)
-						validMetrics = false
+						log.Debugf("Metrics: Skipping metric, metric is not enabled for key [%s]", key)
 					}
 				}
 			}
@@ -194,13 +275,13 @@ func updateMetrics(metrics map[string]*metricData) {
 
 	for _, metricClass := range mqmetric.Metrics.Classes {
 		for _, metricType := range metricClass.Types {
-			if !strings.Contains(metricType.ObjectTopic, "%s") {
+			if isSupportedType(metricType) {
 				for _, metricElement := range metricType.Elements {
 
-					// Unexpected metric elements (with no defined mapping) are handled in 'initialiseMetrics'
-					// - if any exist, they are logged as errors and skipped (they are not added to the metrics map)
-					// Therefore we can ignore handling any unexpected metric elements found here
-					// - this avoids us logging excessive errors, as this function is called frequently
+					// Metric elements are added to the metrics map in 'initialiseMetrics'
+					// - unmapped metrics are given a generated name there, so only disabled or duplicate metrics
+					//   are missing from the map, and they are ignored here
+					// - this avoids us logging excessive messages, as this function is called frequently
 					metric, ok := metrics[makeKey(metricElement)]
 					if ok {
 						// Clear existing metric values
@@ -221,6 +302,17 @@ func updateMetrics(metrics map[string]*metricData) {
 	}
 }
 
+// isObjectType returns true if the metric type is published separately for each object, rather than for the queue manager
+func isObjectType(metricType *mqmetric.MonType) bool {
+	return strings.Contains(metricType.ObjectTopic, "%s")
+}
+
+// isSupportedType returns true if metrics of this type are exported
+// - queue manager metrics are always exported, and object metrics are exported for queues only
+func isSupportedType(metricType *mqmetric.MonType) bool {
+	return !isObjectType(metricType) || metricType.Parent.Name == queueClassName
+}
+
 // makeKey builds a unique key for each metric
 func makeKey(metricElement *mqmetric.MonElement) string {
 	return metricElement.Parent.Parent.Name + "/" + metricElement.Parent.Name + "/" + metricElement.Description
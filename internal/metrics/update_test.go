@@ -39,7 +39,7 @@ func TestInitialiseMetrics(t *testing.T) {
 	teardownTestCase := setupTestCase(false)
 	defer teardownTestCase()
 
-	metrics, err := initialiseMetrics(getTestLogger())
+	metrics, err := initialiseMetrics(getTestLogger(), generateMetricNamesMap())
 	metric, ok := metrics[testKey1]
 
 	if err != nil {
@@ -77,10 +77,36 @@ func TestInitialiseMetrics_UnexpectedKey(t *testing.T) {
 	defer teardownTestCase()
 
 	mqmetric.Metrics.Classes[0].Types[0].Elements[0].Description = "New Metric"
-	_, err := initialiseMetrics(getTestLogger())
+	metrics, err := initialiseMetrics(getTestLogger(), generateMetricNamesMap())
+
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	metric, ok := metrics[testClassName+"/"+testTypeName+"/New Metric"]
+	if !ok {
+		t.Fatal("Expected metric with generated name not found in map")
+	}
+	expected := "cpu_systemsummary_new_metric"
+	if metric.name != expected {
+		t.Errorf("Expected name=%s; actual %s", expected, metric.name)
+	}
+}
+
+func TestInitialiseMetrics_DuplicateNames(t *testing.T) {
+
+	teardownTestCase := setupTestCase(false)
+	defer teardownTestCase()
+
+	metricNamesMap := generateMetricNamesMap()
+	metricNamesMap[testKey1] = metricLookup{"ram_free_percentage", true}
+	metricElement := *mqmetric.Metrics.Classes[0].Types[0].Elements[0]
+	metricElement.Description = "RAM free percentage"
+	mqmetric.Metrics.Classes[0].Types[0].Elements[1] = &metricElement
+
+	_, err := initialiseMetrics(getTestLogger(), metricNamesMap)
 
 	if err == nil {
-		t.Error("Expected skipping metric error")
+		t.Error("Expected duplicate names error")
 	}
 }
 
@@ -89,7 +115,7 @@ func TestInitialiseMetrics_DuplicateKeys(t *testing.T) {
 	teardownTestCase := setupTestCase(true)
 	defer teardownTestCase()
 
-	_, err := initialiseMetrics(getTestLogger())
+	_, err := initialiseMetrics(getTestLogger(), generateMetricNamesMap())
 
 	if err == nil {
 		t.Error("Expected duplicate keys error")
@@ -101,7 +127,7 @@ func TestUpdateMetrics(t *testing.T) {
 	teardownTestCase := setupTestCase(false)
 	defer teardownTestCase()
 
-	metrics, _ := initialiseMetrics(getTestLogger())
+	metrics, _ := initialiseMetrics(getTestLogger(), generateMetricNamesMap())
 	updateMetrics(metrics)
 
 	metric, _ := metrics[testKey1]
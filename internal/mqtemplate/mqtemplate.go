@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2020
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -50,7 +50,7 @@ func ProcessTemplateFile(templateFile, destFile string, data interface{}, log *l
 	}
 
 	// #nosec G302 G304 G306 - its a read by owner/s group, and pose no harm.
-	f, err := os.OpenFile(destFile, os.O_CREATE|os.O_WRONLY, 0660)
+	f, err := os.OpenFile(destFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0660)
 	// #nosec G307 - local to this function, pose no harm.
 	defer f.Close()
 	err = t.Execute(f, data)
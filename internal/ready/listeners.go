@@ -0,0 +1,152 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"context"
+	cryptotls "crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListenerPort = 1414
+	listenerDialTimeout = 5 * time.Second
+	maxChannelNameLen   = 20
+)
+
+// channelName matches the characters which are valid in an MQ channel name
+var channelName = regexp.MustCompile(`^[A-Za-z0-9./_%]+$`)
+
+// Listener is a queue manager listener which is checked for readiness
+type Listener struct {
+	Port int
+	// TLS is true if the listener is used for TLS channels, which is shown in status reports
+	TLS bool
+	// ProbeChannel is the name of a channel which is used to complete a TLS handshake with the listener, or empty
+	// if the listener is checked using a TCP connection
+	ProbeChannel string
+}
+
+// Address returns the local address of the listener
+func (l Listener) Address() string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(l.Port))
+}
+
+// String returns a description of the listener, for use in messages
+func (l Listener) String() string {
+	if l.TLS {
+		return l.Address() + " (TLS)"
+	}
+	return l.Address()
+}
+
+// GetListeners returns the queue manager listeners set in MQ_LISTENER_PORTS, or the default listener on port 1414.
+// MQ_LISTENER_PORTS is a comma-separated list of ports, each of which can have a "/tls" suffix, for example
+// "1414,1415/tls".  The TLS listeners are checked with a TLS handshake if MQ_LISTENER_TLS_PROBE_CHANNEL is set.
+func GetListeners() ([]Listener, error) {
+	value := strings.TrimSpace(os.Getenv("MQ_LISTENER_PORTS"))
+	if value == "" {
+		return []Listener{{Port: defaultListenerPort}}, nil
+	}
+	probeChannel := strings.TrimSpace(os.Getenv("MQ_LISTENER_TLS_PROBE_CHANNEL"))
+	if probeChannel != "" && (len(probeChannel) > maxChannelNameLen || !channelName.MatchString(probeChannel)) {
+		return nil, fmt.Errorf("Invalid channel name %q in MQ_LISTENER_TLS_PROBE_CHANNEL", probeChannel)
+	}
+
+	listeners := []Listener{}
+	seen := make(map[int]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		port, option, hasOption := strings.Cut(entry, "/")
+		if hasOption && !strings.EqualFold(option, "tls") {
+			return nil, fmt.Errorf("Invalid option %q for port %s in MQ_LISTENER_PORTS", option, port)
+		}
+		number, err := strconv.Atoi(port)
+		if err != nil || number <= 0 || number > 65535 {
+			return nil, fmt.Errorf("Invalid port %q in MQ_LISTENER_PORTS", port)
+		}
+		if seen[number] {
+			return nil, fmt.Errorf("Port %d is specified more than once in MQ_LISTENER_PORTS", number)
+		}
+		seen[number] = true
+		listener := Listener{Port: number, TLS: hasOption}
+		if hasOption {
+			listener.ProbeChannel = probeChannel
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("MQ_LISTENER_PORTS must contain at least one port")
+	}
+	return listeners, nil
+}
+
+// CheckListener checks that a listener is accepting connections.  A TCP connection is used unless the listener has
+// a probe channel, because a TLS handshake which does not name a channel can be rejected and logged as an error by
+// the queue manager each time the check is run.  The probe channel is named using Server Name Indication (SNI), in
+// the same way as MQ clients, so the queue manager uses the certificate for that channel.
+func CheckListener(ctx context.Context, l Listener) error {
+	dialer := &net.Dialer{Timeout: listenerDialTimeout}
+	if l.ProbeChannel == "" {
+		conn, err := dialer.DialContext(ctx, "tcp", l.Address())
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	tlsDialer := &cryptotls.Dialer{
+		NetDialer: dialer,
+		// #nosec G402 - the handshake only checks that the listener is serving TLS, and no data is exchanged
+		Config: &cryptotls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         cryptotls.VersionTLS12,
+			ServerName:         channelServerName(l.ProbeChannel),
+		},
+	}
+	conn, err := tlsDialer.DialContext(ctx, "tcp", l.Address())
+	if err != nil {
+		return fmt.Errorf("TLS handshake using channel %s failed: %v", l.ProbeChannel, err)
+	}
+	return conn.Close()
+}
+
+// channelServerName returns the SNI host name which MQ uses for a channel.  Upper case letters are changed to lower
+// case and digits are unchanged, and every other character is changed to its hexadecimal value followed by "-",
+// for example "MY.SVRCONN" is "my2e-svrconn.chl.mq.ibm.com".
+func channelServerName(channel string) string {
+	var name strings.Builder
+	for _, c := range channel {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			name.WriteRune(c - 'A' + 'a')
+		case c >= '0' && c <= '9':
+			name.WriteRune(c)
+		default:
+			fmt.Fprintf(&name, "%02x-", c)
+		}
+	}
+	return name.String() + ".chl.mq.ibm.com"
+}
@@ -0,0 +1,171 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"context"
+	cryptotls "crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var getListenersTests = []struct {
+	value     string
+	expected  []Listener
+	expectErr bool
+}{
+	{"", []Listener{{Port: 1414}}, false},
+	{"1414", []Listener{{Port: 1414}}, false},
+	{"1414,1415", []Listener{{Port: 1414}, {Port: 1415}}, false},
+	{" 1414 , 1415/tls ,", []Listener{{Port: 1414}, {Port: 1415, TLS: true}}, false},
+	{"1415/TLS,1414", []Listener{{Port: 1415, TLS: true}, {Port: 1414}}, false},
+	{"1414,1414/tls", nil, true},
+	{"1414,1414", nil, true},
+	{"1414/ssl", nil, true},
+	{"abc", nil, true},
+	{"0", nil, true},
+	{"65536", nil, true},
+	{"-1414", nil, true},
+	{"/tls", nil, true},
+	{",", nil, true},
+}
+
+func TestGetListeners(t *testing.T) {
+	defer os.Unsetenv("MQ_LISTENER_PORTS")
+	for _, test := range getListenersTests {
+		t.Run(test.value, func(t *testing.T) {
+			os.Setenv("MQ_LISTENER_PORTS", test.value)
+			listeners, err := GetListeners()
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error for MQ_LISTENER_PORTS=%q; actual listeners %v", test.value, listeners)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for MQ_LISTENER_PORTS=%q: %v", test.value, err)
+			}
+			if !reflect.DeepEqual(listeners, test.expected) {
+				t.Errorf("Expected listeners=%v; actual %v", test.expected, listeners)
+			}
+		})
+	}
+}
+
+func TestGetListeners_ProbeChannel(t *testing.T) {
+	t.Setenv("MQ_LISTENER_PORTS", "1414,1415/tls")
+	t.Setenv("MQ_LISTENER_TLS_PROBE_CHANNEL", " PROBE.SVRCONN ")
+	listeners, err := GetListeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Listener{{Port: 1414}, {Port: 1415, TLS: true, ProbeChannel: "PROBE.SVRCONN"}}
+	if !reflect.DeepEqual(listeners, expected) {
+		t.Errorf("Expected listeners=%v; actual %v", expected, listeners)
+	}
+
+	for _, channel := range []string{"PROBE SVRCONN", "ABCDEFGHIJKLMNOPQRSTU"} {
+		t.Setenv("MQ_LISTENER_TLS_PROBE_CHANNEL", channel)
+		_, err = GetListeners()
+		if err == nil {
+			t.Errorf("Expected an error for MQ_LISTENER_TLS_PROBE_CHANNEL=%q", channel)
+		}
+	}
+}
+
+var channelServerNameTests = []struct {
+	channel  string
+	expected string
+}{
+	{"PROBE", "probe.chl.mq.ibm.com"},
+	{"MY.SVRCONN", "my2e-svrconn.chl.mq.ibm.com"},
+	{"APP_1/a%", "app5f-12f-61-25-.chl.mq.ibm.com"},
+}
+
+func TestChannelServerName(t *testing.T) {
+	for _, test := range channelServerNameTests {
+		t.Run(test.channel, func(t *testing.T) {
+			name := channelServerName(test.channel)
+			if name != test.expected {
+				t.Errorf("Expected %q; actual %q", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestCheckListener_ProbeChannel(t *testing.T) {
+	serverNames := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &cryptotls.Config{
+		GetConfigForClient: func(hello *cryptotls.ClientHelloInfo) (*cryptotls.Config, error) {
+			serverNames <- hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	err := CheckListener(context.Background(), Listener{Port: port, TLS: true, ProbeChannel: "MY.SVRCONN"})
+	if err != nil {
+		t.Fatalf("Expected TLS handshake to succeed; actual error %v", err)
+	}
+	if name := <-serverNames; name != "my2e-svrconn.chl.mq.ibm.com" {
+		t.Errorf("Expected the probe channel to be named using SNI; actual server name %q", name)
+	}
+}
+
+func TestCheckListener(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := server.Addr().(*net.TCPAddr).Port
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// A TLS listener without a probe channel is checked using a TCP connection too
+	for _, listener := range []Listener{{Port: port}, {Port: port, TLS: true}} {
+		err = CheckListener(context.Background(), listener)
+		if err != nil {
+			t.Errorf("Expected listener %v to be available; actual error %v", listener, err)
+		}
+	}
+
+	// A TLS handshake fails with a listener which is not serving TLS
+	err = CheckListener(context.Background(), Listener{Port: port, TLS: true, ProbeChannel: "PROBE.SVRCONN"})
+	if err == nil {
+		t.Errorf("Expected an error completing a TLS handshake on port %d", port)
+	}
+
+	server.Close()
+	err = CheckListener(context.Background(), Listener{Port: port})
+	if err == nil {
+		t.Errorf("Expected an error checking closed listener on port %d", port)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/command"
+)
+
+const nativeHAStatusFileName string = "/run/runmqserver/nativeha-status.json"
+
+// nativeHAAttribute matches an attribute in the output of "dspmq -o nativeha", for example "ROLE(Active)"
+var nativeHAAttribute = regexp.MustCompile(`([A-Z]+)\(([^)]*)\)`)
+
+// NativeHAStatus is the native HA status of the queue manager, as seen by this instance
+type NativeHAStatus struct {
+	QueueManager string                   `json:"queueManager"`
+	Instance     string                   `json:"instance"`
+	Role         string                   `json:"role"`
+	InSync       bool                     `json:"inSync"`
+	Quorum       string                   `json:"quorum,omitempty"`
+	Instances    []NativeHAInstanceStatus `json:"instances"`
+	Updated      time.Time                `json:"updated"`
+}
+
+// NativeHAInstanceStatus is the status of one instance of a native HA queue manager
+type NativeHAInstanceStatus struct {
+	Name               string `json:"name"`
+	Role               string `json:"role"`
+	ReplicationAddress string `json:"replicationAddress,omitempty"`
+	Connected          bool   `json:"connected"`
+	InSync             bool   `json:"inSync"`
+	// Backlog is the number of bytes of log data not yet replicated to the instance, or -1 if not known
+	Backlog int64 `json:"backlog"`
+}
+
+// GetNativeHAStatus returns the native HA status of the queue manager, using "dspmq -o nativeha"
+func GetNativeHAStatus(ctx context.Context, name string) (NativeHAStatus, error) {
+	out, _, err := command.RunContext(ctx, "dspmq", "-o", "nativeha", "-x", "-m", name)
+	if err != nil {
+		return NativeHAStatus{}, err
+	}
+	return parseNativeHAStatus(out, time.Now())
+}
+
+// parseNativeHAStatus parses the output of "dspmq -o nativeha -x", which has a line for the queue manager
+// followed by a line for each instance
+func parseNativeHAStatus(out string, now time.Time) (NativeHAStatus, error) {
+	status := NativeHAStatus{Instances: []NativeHAInstanceStatus{}, Updated: now.UTC()}
+	for _, line := range strings.Split(out, "\n") {
+		attributes := make(map[string]string)
+		for _, match := range nativeHAAttribute.FindAllStringSubmatch(line, -1) {
+			// The first occurrence is used, as the queue manager line also includes the name of this instance
+			if _, ok := attributes[match[1]]; !ok {
+				attributes[match[1]] = strings.TrimSpace(match[2])
+			}
+		}
+		if qmName, ok := attributes["QMNAME"]; ok {
+			status.QueueManager = qmName
+			status.Instance = attributes["INSTANCE"]
+			status.Role = attributes["ROLE"]
+			status.InSync = isYes(attributes["INSYNC"])
+			status.Quorum = attributes["QUORUM"]
+		} else if instance, ok := attributes["INSTANCE"]; ok {
+			backlog, err := strconv.ParseInt(attributes["BACKLOG"], 10, 64)
+			if err != nil {
+				backlog = -1
+			}
+			status.Instances = append(status.Instances, NativeHAInstanceStatus{
+				Name:               instance,
+				Role:               attributes["ROLE"],
+				ReplicationAddress: attributes["REPLADDR"],
+				Connected:          isYes(attributes["CONNACTV"]),
+				InSync:             isYes(attributes["INSYNC"]),
+				Backlog:            backlog,
+			})
+		}
+	}
+	if status.QueueManager == "" {
+		return NativeHAStatus{}, fmt.Errorf("Failed to find native HA status in dspmq output: %s", strings.TrimSpace(out))
+	}
+	return status, nil
+}
+
+func isYes(value string) bool {
+	return strings.EqualFold(value, "yes")
+}
+
+// WriteNativeHAStatus saves the native HA status, for use by other processes
+func WriteNativeHAStatus(status NativeHAStatus) error {
+	buf, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that readers never see a partially written file
+	tempFileName := nativeHAStatusFileName + ".tmp"
+	// #nosec G306 - this gives permissions to owner/s group only.
+	err = os.WriteFile(tempFileName, buf, 0660)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFileName, nativeHAStatusFileName)
+}
+
+// ReadNativeHAStatus returns the native HA status last saved by runmqserver, or nil if there is none
+func ReadNativeHAStatus() (*NativeHAStatus, error) {
+	buf, err := os.ReadFile(nativeHAStatusFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var status NativeHAStatus
+	err = json.Unmarshal(buf, &status)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse native HA status in %s: %v", nativeHAStatusFileName, err)
+	}
+	return &status, nil
+}
+
+// Summary returns a description of the native HA status, suitable for output from probes
+func (s NativeHAStatus) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Native HA instance %s is %s", s.Instance, s.Role)
+	if s.InSync {
+		b.WriteString(", in sync")
+	} else {
+		b.WriteString(", not in sync")
+	}
+	if s.Quorum != "" {
+		fmt.Fprintf(&b, ", quorum %s", s.Quorum)
+	}
+	fmt.Fprintf(&b, " (updated %s)\n", s.Updated.Format(time.RFC3339))
+	for _, instance := range s.Instances {
+		connected := "connected"
+		if !instance.Connected {
+			connected = "not connected"
+		}
+		inSync := "in sync"
+		if !instance.InSync {
+			inSync = "not in sync"
+		}
+		backlog := "unknown"
+		if instance.Backlog >= 0 {
+			backlog = fmt.Sprintf("%d bytes", instance.Backlog)
+		}
+		fmt.Fprintf(&b, "  %s: %s, %s, %s, backlog %s\n", instance.Name, instance.Role, connected, inSync, backlog)
+	}
+	return b.String()
+}
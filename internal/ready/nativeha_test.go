@@ -0,0 +1,117 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const activeOutput = `QMNAME(QM1)                                               ROLE(Active) INSTANCE(inst1) INSYNC(yes) QUORUM(3/3)
+ INSTANCE(inst1) ROLE(Active) REPLADDR(9.20.123.45) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst2) ROLE(Replica) REPLADDR(9.20.123.46) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst3) ROLE(Replica) REPLADDR(9.20.123.47) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+`
+
+const replicaOutput = `QMNAME(QM1)                                               ROLE(Replica) INSTANCE(inst2) INSYNC(yes) QUORUM(3/3)
+ INSTANCE(inst1) ROLE(Active) REPLADDR(9.20.123.45) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst2) ROLE(Replica) REPLADDR(9.20.123.46) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst3) ROLE(Replica) REPLADDR(9.20.123.47) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+`
+
+const outOfSyncOutput = `QMNAME(QM1)                                               ROLE(Active) INSTANCE(inst1) INSYNC(no) QUORUM(2/3)
+ INSTANCE(inst1) ROLE(Active) REPLADDR(9.20.123.45) CONNACTV(yes) INSYNC(yes) BACKLOG(0) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst2) ROLE(Replica) REPLADDR(9.20.123.46) CONNACTV(yes) INSYNC(no) BACKLOG(4096) CONNINST(yes) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+ INSTANCE(inst3) ROLE(Unknown) REPLADDR(9.20.123.47) CONNACTV(no) INSYNC(no) BACKLOG(?) CONNINST(no) ALTDATE(2023-03-01) ALTTIME(12.03.44)
+`
+
+var parseNativeHAStatusTests = []struct {
+	name      string
+	out       string
+	expected  NativeHAStatus
+	expectErr bool
+}{
+	{
+		name: "Active",
+		out:  activeOutput,
+		expected: NativeHAStatus{
+			QueueManager: "QM1", Instance: "inst1", Role: "Active", InSync: true, Quorum: "3/3",
+			Instances: []NativeHAInstanceStatus{
+				{Name: "inst1", Role: "Active", ReplicationAddress: "9.20.123.45", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst2", Role: "Replica", ReplicationAddress: "9.20.123.46", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst3", Role: "Replica", ReplicationAddress: "9.20.123.47", Connected: true, InSync: true, Backlog: 0},
+			},
+		},
+	},
+	{
+		name: "Replica",
+		out:  replicaOutput,
+		expected: NativeHAStatus{
+			QueueManager: "QM1", Instance: "inst2", Role: "Replica", InSync: true, Quorum: "3/3",
+			Instances: []NativeHAInstanceStatus{
+				{Name: "inst1", Role: "Active", ReplicationAddress: "9.20.123.45", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst2", Role: "Replica", ReplicationAddress: "9.20.123.46", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst3", Role: "Replica", ReplicationAddress: "9.20.123.47", Connected: true, InSync: true, Backlog: 0},
+			},
+		},
+	},
+	{
+		name: "OutOfSync",
+		out:  outOfSyncOutput,
+		expected: NativeHAStatus{
+			QueueManager: "QM1", Instance: "inst1", Role: "Active", InSync: false, Quorum: "2/3",
+			Instances: []NativeHAInstanceStatus{
+				{Name: "inst1", Role: "Active", ReplicationAddress: "9.20.123.45", Connected: true, InSync: true, Backlog: 0},
+				{Name: "inst2", Role: "Replica", ReplicationAddress: "9.20.123.46", Connected: true, InSync: false, Backlog: 4096},
+				{Name: "inst3", Role: "Unknown", ReplicationAddress: "9.20.123.47", Connected: false, InSync: false, Backlog: -1},
+			},
+		},
+	},
+	{
+		name:      "Unparsable",
+		out:       "AMQ7048E: The queue manager name is either not valid or not known.\n",
+		expectErr: true,
+	},
+	{
+		name:      "Empty",
+		out:       "",
+		expectErr: true,
+	},
+}
+
+func TestParseNativeHAStatus(t *testing.T) {
+	now := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range parseNativeHAStatusTests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := parseNativeHAStatus(test.out, now)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error parsing %q; actual status %+v", test.out, status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %s", err.Error())
+			}
+			test.expected.Updated = now
+			if !reflect.DeepEqual(status, test.expected) {
+				t.Errorf("Expected status=%+v; actual %+v", test.expected, status)
+			}
+		})
+	}
+}
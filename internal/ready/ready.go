@@ -19,8 +19,9 @@ package ready
 
 import (
 	"context"
+	"fmt"
 	"os"
-	"strings"
+	"regexp"
 
 	"github.com/ibm-messaging/mq-container/internal/command"
 )
@@ -45,7 +46,14 @@ func Clear() error {
 		return err
 	}
 	if exist {
-		return os.Remove(fileName)
+		err = os.Remove(fileName)
+		if err != nil {
+			return err
+		}
+	}
+	err = os.Remove(nativeHAStatusFileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 	return nil
 }
@@ -67,22 +75,51 @@ func Check() (bool, error) {
 	return exists, nil
 }
 
+// dspmqStatus matches the status of the queue manager in the output of "dspmq -n"
+var dspmqStatus = regexp.MustCompile(`STATUS\(([^)]*)\)`)
+
 // Status returns an enum representing the current running status of the queue manager
 func Status(ctx context.Context, name string) (QMStatus, error) {
 	out, _, err := command.RunContext(ctx, "dspmq", "-n", "-m", name)
 	if err != nil {
 		return StatusUnknown, err
 	}
-	if strings.Contains(string(out), "(RUNNING)") {
-		return StatusActiveQM, nil
+	return StatusFromState(parseState(out)), nil
+}
+
+// State returns the untranslated state of the queue manager from "dspmq", for example "RUNNING"
+func State(ctx context.Context, name string) (string, error) {
+	out, _, err := command.RunContext(ctx, "dspmq", "-n", "-m", name)
+	if err != nil {
+		return "", err
+	}
+	state := parseState(out)
+	if state == "" {
+		return "", fmt.Errorf("Failed to find queue manager status in dspmq output: %s", out)
 	}
-	if strings.Contains(string(out), "(RUNNING AS STANDBY)") {
-		return StatusStandbyQM, nil
+	return state, nil
+}
+
+// parseState returns the untranslated state in the output of "dspmq -n", or an empty string if it isn't found
+func parseState(out string) string {
+	match := dspmqStatus.FindStringSubmatch(out)
+	if match == nil {
+		return ""
 	}
-	if strings.Contains(string(out), "(REPLICA)") {
-		return StatusStandbyQM, nil
+	return match[1]
+}
+
+// StatusFromState returns the status for an untranslated state of the queue manager
+func StatusFromState(state string) QMStatus {
+	switch state {
+	case "RUNNING":
+		return StatusActiveQM
+	case "RUNNING AS STANDBY":
+		return StatusStandbyQM
+	case "REPLICA":
+		return StatusStandbyQM
 	}
-	return StatusUnknown, nil
+	return StatusUnknown
 }
 
 type QMStatus int
@@ -102,3 +139,29 @@ func (s QMStatus) StandbyQM() bool { return s == StatusStandbyQM }
 
 // ReplicaQM returns true if the queue manager is running in replica mode
 func (s QMStatus) ReplicaQM() bool { return s == StatusReplicaQM }
+
+// Roles of an instance of the queue manager
+const (
+	RoleActive  = "active"
+	RoleStandby = "standby"
+	RoleReplica = "replica"
+	RoleUnknown = "unknown"
+)
+
+// Role returns the role of this instance of the queue manager: active, standby, replica or unknown
+func (s QMStatus) Role() string {
+	switch {
+	case s.ActiveQM():
+		return RoleActive
+	case s.ReplicaQM():
+		return RoleReplica
+	case s.StandbyQM():
+		// Replicas are reported as standby instances, so use the type of high availability to distinguish them
+		if os.Getenv("MQ_NATIVE_HA") == "true" {
+			return RoleReplica
+		}
+		return RoleStandby
+	default:
+		return RoleUnknown
+	}
+}
@@ -0,0 +1,51 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"testing"
+)
+
+var roleTests = []struct {
+	out      string
+	nativeHA string
+	state    string
+	role     string
+}{
+	{"QMNAME(QM1)                                               STATUS(RUNNING)\n", "", "RUNNING", RoleActive},
+	{"QMNAME(QM1)                                               STATUS(RUNNING AS STANDBY)\n", "", "RUNNING AS STANDBY", RoleStandby},
+	{"QMNAME(QM1)                                               STATUS(RUNNING AS STANDBY)\n", "true", "RUNNING AS STANDBY", RoleReplica},
+	{"QMNAME(QM1)                                               STATUS(REPLICA)\n", "true", "REPLICA", RoleReplica},
+	{"QMNAME(QM1)                                               STATUS(ENDED NORMALLY)\n", "", "ENDED NORMALLY", RoleUnknown},
+	{"AMQ7048E: The queue manager name is either not valid or not known.\n", "", "", RoleUnknown},
+}
+
+func TestRole(t *testing.T) {
+	for _, test := range roleTests {
+		t.Run(test.state+"/"+test.nativeHA, func(t *testing.T) {
+			t.Setenv("MQ_NATIVE_HA", test.nativeHA)
+			state := parseState(test.out)
+			if state != test.state {
+				t.Errorf("Expected state %q; got %q", test.state, state)
+			}
+			role := StatusFromState(state).Role()
+			if role != test.role {
+				t.Errorf("Expected role %q; got %q", test.role, role)
+			}
+		})
+	}
+}
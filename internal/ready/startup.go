@@ -0,0 +1,167 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// startupFileName is the file used to share the startup state with other processes
+var startupFileName = "/run/runmqserver/startup.json"
+
+// Phases of runmqserver initialization
+const (
+	PhaseLicense   = "license"
+	PhaseVolumes   = "volumes"
+	PhaseCrtmqdir  = "crtmqdir"
+	PhaseTLS       = "tls"
+	PhaseWebConfig = "webconfig"
+	PhaseNativeHA  = "nativeha"
+	PhaseCrtmqm    = "crtmqm"
+	PhaseStrmqm    = "strmqm"
+	PhaseMetrics   = "metrics"
+	PhaseStarted   = "started"
+)
+
+var phaseDescriptions = map[string]string{
+	PhaseLicense:   "Checking license acceptance",
+	PhaseVolumes:   "Creating volumes",
+	PhaseCrtmqdir:  "Creating MQ directory structure",
+	PhaseTLS:       "Configuring TLS keystores",
+	PhaseWebConfig: "Configuring web server",
+	PhaseNativeHA:  "Configuring native HA",
+	PhaseCrtmqm:    "Creating queue manager",
+	PhaseStrmqm:    "Starting queue manager",
+	PhaseMetrics:   "Starting metrics",
+	PhaseStarted:   "Started",
+}
+
+// StartupState is the progress of runmqserver through its initialization phases
+type StartupState struct {
+	Phase        string         `json:"phase"`
+	Description  string         `json:"description"`
+	PhaseStarted time.Time      `json:"phaseStarted"`
+	Started      time.Time      `json:"started"`
+	Completed    []StartupPhase `json:"completed"`
+	Failed       bool           `json:"failed"`
+	Error        string         `json:"error,omitempty"`
+	Updated      time.Time      `json:"updated"`
+}
+
+// StartupPhase is a completed initialization phase
+type StartupPhase struct {
+	Phase    string    `json:"phase"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+	Duration string    `json:"duration"`
+}
+
+var (
+	startupMutex sync.Mutex
+	startup      *StartupState
+)
+
+// SetStartupPhase records that runmqserver has started a new initialization phase
+func SetStartupPhase(phase string) error {
+	startupMutex.Lock()
+	defer startupMutex.Unlock()
+
+	now := time.Now().UTC()
+	if startup == nil {
+		startup = &StartupState{Started: now, Completed: []StartupPhase{}}
+	} else if startup.Phase != "" {
+		startup.Completed = append(startup.Completed, StartupPhase{
+			Phase:    startup.Phase,
+			Started:  startup.PhaseStarted,
+			Ended:    now,
+			Duration: now.Sub(startup.PhaseStarted).Round(time.Millisecond).String(),
+		})
+	}
+	startup.Phase = phase
+	startup.Description = phaseDescriptions[phase]
+	startup.PhaseStarted = now
+	return writeStartupState(now)
+}
+
+// SetStartupFailed records that the current initialization phase has failed
+func SetStartupFailed(reason string) error {
+	startupMutex.Lock()
+	defer startupMutex.Unlock()
+
+	if startup == nil || startup.Phase == PhaseStarted {
+		return nil
+	}
+	startup.Failed = true
+	startup.Error = reason
+	return writeStartupState(time.Now().UTC())
+}
+
+// writeStartupState saves the startup state, for use by other processes
+func writeStartupState(now time.Time) error {
+	startup.Updated = now
+	buf, err := json.MarshalIndent(startup, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that readers never see a partially written file
+	tempFileName := startupFileName + ".tmp"
+	// #nosec G306 - this gives permissions to owner/s group only.
+	err = os.WriteFile(tempFileName, buf, 0660)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFileName, startupFileName)
+}
+
+// ReadStartupState returns the startup state last saved by runmqserver, or nil if there is none
+func ReadStartupState() (*StartupState, error) {
+	buf, err := os.ReadFile(startupFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state StartupState
+	err = json.Unmarshal(buf, &state)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse startup state in %s: %v", startupFileName, err)
+	}
+	return &state, nil
+}
+
+// Summary returns a description of the startup state, suitable for output from probes
+func (s StartupState) Summary(now time.Time) string {
+	var b strings.Builder
+	switch {
+	case s.Failed:
+		fmt.Fprintf(&b, "Startup failed in phase %s (%s) after %v: %s\n", s.Phase, s.Description, now.Sub(s.PhaseStarted).Round(time.Second), s.Error)
+	case s.Phase == PhaseStarted:
+		fmt.Fprintf(&b, "Startup completed in %v\n", s.PhaseStarted.Sub(s.Started).Round(time.Millisecond))
+	default:
+		fmt.Fprintf(&b, "Startup phase %s (%s) running for %v, since %s\n", s.Phase, s.Description, now.Sub(s.PhaseStarted).Round(time.Second), s.PhaseStarted.Format(time.RFC3339))
+	}
+	for _, phase := range s.Completed {
+		fmt.Fprintf(&b, "  %s: %s\n", phase.Phase, phase.Duration)
+	}
+	return b.String()
+}
@@ -0,0 +1,198 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ready
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupStartupTest writes the startup state to a temporary directory, and clears any state from previous tests
+func setupStartupTest(t *testing.T) {
+	original := startupFileName
+	startupFileName = filepath.Join(t.TempDir(), "startup.json")
+	startup = nil
+	t.Cleanup(func() {
+		startupFileName = original
+		startup = nil
+	})
+}
+
+func TestReadStartupState_NoFile(t *testing.T) {
+	setupStartupTest(t)
+	state, err := ReadStartupState()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if state != nil {
+		t.Errorf("Expected no startup state; actual %+v", state)
+	}
+}
+
+func TestReadStartupState_Invalid(t *testing.T) {
+	setupStartupTest(t)
+	err := os.WriteFile(startupFileName, []byte("{"), 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadStartupState()
+	if err == nil {
+		t.Error("Expected an error reading an invalid startup state")
+	}
+}
+
+func TestSetStartupPhase(t *testing.T) {
+	setupStartupTest(t)
+	phases := []string{PhaseLicense, PhaseVolumes, PhaseCrtmqdir}
+	for _, phase := range phases {
+		err := SetStartupPhase(phase)
+		if err != nil {
+			t.Fatalf("Unexpected error setting phase %s: %v", phase, err)
+		}
+	}
+
+	state, err := ReadStartupState()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if state.Phase != PhaseCrtmqdir {
+		t.Errorf("Expected phase=%s; actual %s", PhaseCrtmqdir, state.Phase)
+	}
+	if state.Description != phaseDescriptions[PhaseCrtmqdir] {
+		t.Errorf("Expected description=%s; actual %s", phaseDescriptions[PhaseCrtmqdir], state.Description)
+	}
+	if state.Failed {
+		t.Error("Expected startup not to have failed")
+	}
+	if len(state.Completed) != 2 || state.Completed[0].Phase != PhaseLicense || state.Completed[1].Phase != PhaseVolumes {
+		t.Errorf("Expected completed phases [%s %s]; actual %+v", PhaseLicense, PhaseVolumes, state.Completed)
+	}
+	if state.Started.After(state.PhaseStarted) {
+		t.Errorf("Expected startup to start before the current phase; actual started %v, phase started %v", state.Started, state.PhaseStarted)
+	}
+
+	info, err := os.Stat(startupFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0111 != 0 {
+		t.Errorf("Expected startup state file not to be executable; actual mode %v", info.Mode())
+	}
+	if _, err := os.Stat(startupFileName + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary file to be removed; actual error %v", err)
+	}
+}
+
+func TestSetStartupFailed(t *testing.T) {
+	setupStartupTest(t)
+	err := SetStartupPhase(PhaseLicense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SetStartupFailed("license not accepted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := ReadStartupState()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if !state.Failed || state.Error != "license not accepted" {
+		t.Errorf("Expected failed state with error; actual failed=%v, error=%q", state.Failed, state.Error)
+	}
+	if state.Phase != PhaseLicense {
+		t.Errorf("Expected phase=%s; actual %s", PhaseLicense, state.Phase)
+	}
+}
+
+func TestSetStartupFailed_AfterStarted(t *testing.T) {
+	setupStartupTest(t)
+	for _, phase := range []string{PhaseStrmqm, PhaseStarted} {
+		err := SetStartupPhase(phase)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Failures after startup has completed are not startup failures
+	err := SetStartupFailed("queue manager ended")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := ReadStartupState()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if state.Failed {
+		t.Errorf("Expected startup not to have failed; actual error %q", state.Error)
+	}
+}
+
+func TestSetStartupFailed_NoPhase(t *testing.T) {
+	setupStartupTest(t)
+	err := SetStartupFailed("failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(startupFileName); !os.IsNotExist(err) {
+		t.Errorf("Expected no startup state to be written; actual error %v", err)
+	}
+}
+
+func TestStartupStateSummary(t *testing.T) {
+	started := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	completed := []StartupPhase{
+		{Phase: PhaseLicense, Started: started, Ended: started.Add(time.Second), Duration: "1s"},
+	}
+	now := started.Add(time.Minute)
+
+	var summaryTests = []struct {
+		name     string
+		state    StartupState
+		expected []string
+	}{
+		{
+			name:     "InProgress",
+			state:    StartupState{Phase: PhaseCrtmqm, Description: phaseDescriptions[PhaseCrtmqm], Started: started, PhaseStarted: started.Add(30 * time.Second), Completed: completed},
+			expected: []string{"Startup phase crtmqm (Creating queue manager) running for 30s, since 2023-03-01T12:00:30Z", "  license: 1s"},
+		},
+		{
+			name:     "Failed",
+			state:    StartupState{Phase: PhaseTLS, Description: phaseDescriptions[PhaseTLS], Started: started, PhaseStarted: started.Add(50 * time.Second), Completed: completed, Failed: true, Error: "bad key"},
+			expected: []string{"Startup failed in phase tls (Configuring TLS keystores) after 10s: bad key", "  license: 1s"},
+		},
+		{
+			name:     "Started",
+			state:    StartupState{Phase: PhaseStarted, Description: phaseDescriptions[PhaseStarted], Started: started, PhaseStarted: started.Add(1500 * time.Millisecond), Completed: completed},
+			expected: []string{"Startup completed in 1.5s", "  license: 1s"},
+		},
+	}
+
+	for _, test := range summaryTests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.state.Summary(now)
+			expected := strings.Join(test.expected, "\n") + "\n"
+			if actual != expected {
+				t.Errorf("Expected summary=%q; actual %q", expected, actual)
+			}
+		})
+	}
+}
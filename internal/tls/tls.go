@@ -75,17 +75,25 @@ type TLSStore struct {
 	Truststore KeyStoreData
 }
 
-func configureTLSKeystores(keystoreDir, keyDir, trustDir string, p12TruststoreRequired bool, nativeTLSHA bool) (string, KeyStoreData, KeyStoreData, error) {
+func configureTLSKeystores(keystoreDir, keyDir, trustDir, password string, p12TruststoreRequired bool, nativeTLSHA bool, log *logger.Logger) (string, KeyStoreData, KeyStoreData, error) {
 	var keyLabel string
 	// Create the CMS Keystore & PKCS#12 Truststore (if required)
-	tlsStore, err := generateAllKeystores(keystoreDir, p12TruststoreRequired, nativeTLSHA)
+	tlsStore, err := generateAllKeystores(keystoreDir, password, p12TruststoreRequired, nativeTLSHA)
 	if err != nil {
 		return "", tlsStore.Keystore, tlsStore.Truststore, err
 	}
 
 	if tlsStore.Keystore.Keystore != nil {
 		// Process all keys - add them to the CMS KeyStore
-		keyLabel, err = processKeys(&tlsStore, keystoreDir, keyDir)
+		keyLabel, err = processKeys(&tlsStore, keystoreDir, keyDir, trustDir, log)
+		if err != nil {
+			return "", tlsStore.Keystore, tlsStore.Truststore, err
+		}
+	}
+
+	// Use the default label if it has been set - except for native HA, which has its own keys
+	if !nativeTLSHA {
+		keyLabel, err = selectDefaultLabel(keyLabel, tlsStore.Keystore)
 		if err != nil {
 			return "", tlsStore.Keystore, tlsStore.Truststore, err
 		}
@@ -101,14 +109,14 @@ func configureTLSKeystores(keystoreDir, keyDir, trustDir string, p12TruststoreRe
 }
 
 // ConfigureDefaultTLSKeystores configures the CMS Keystore & PKCS#12 Truststore
-func ConfigureDefaultTLSKeystores() (string, KeyStoreData, KeyStoreData, error) {
-	return configureTLSKeystores(keystoreDirDefault, keyDirDefault, trustDirDefault, true, false)
+func ConfigureDefaultTLSKeystores(log *logger.Logger) (string, KeyStoreData, KeyStoreData, error) {
+	return configureTLSKeystores(keystoreDirDefault, keyDirDefault, trustDirDefault, generateRandomPassword(), true, false, log)
 }
 
 // ConfigureHATLSKeystore configures the CMS Keystore & PKCS#12 Truststore
-func ConfigureHATLSKeystore() (string, KeyStoreData, KeyStoreData, error) {
+func ConfigureHATLSKeystore(log *logger.Logger) (string, KeyStoreData, KeyStoreData, error) {
 	// *.crt files mounted to the HA TLS dir keyDirHA will be processed as trusted in the CMS keystore
-	return configureTLSKeystores(keystoreDirHA, keyDirHA, keyDirHA, false, true)
+	return configureTLSKeystores(keystoreDirHA, keyDirHA, keyDirHA, generateRandomPassword(), false, true, log)
 }
 
 // ConfigureTLS configures TLS for the queue manager
@@ -132,10 +140,26 @@ func ConfigureTLS(keyLabel string, cmsKeystore KeyStoreData, devMode bool, log *
 		}
 	}
 
-	err := mqtemplate.ProcessTemplateFile(mqscTemplate, mqsc, map[string]string{
+	// Set the certificate label for channels which use a different set of keys
+	channelLabels, err := GetChannelLabels(cmsKeystore)
+	if err != nil {
+		return err
+	}
+
+	log = log.With("component", "tls", "keyLabel", keyLabel)
+	log.Debugf("Configuring TLS for the queue manager, with key repository '%s' and FIPS %s", sslKeyRing, fipsEnabled)
+	for _, channel := range channelLabels {
+		log.Debugf("Channel %s uses certificate label %s", channel.Channel, channel.Label)
+	}
+	for _, warning := range checkChannelDefinitions(filepath.Dir(mqsc), filepath.Base(mqsc), channelLabels) {
+		log.Warnf("%s", warning)
+	}
+
+	err = mqtemplate.ProcessTemplateFile(mqscTemplate, mqsc, map[string]interface{}{
 		"SSLKeyR":          sslKeyRing,
 		"CertificateLabel": keyLabel,
 		"SSLFips":          fipsEnabled,
+		"ChannelLabels":    channelLabels,
 	}, log)
 	if err != nil {
 		return err
@@ -176,14 +200,13 @@ func configureTLSDev(log *logger.Logger) error {
 }
 
 // generateAllKeystores creates the CMS Keystore & PKCS#12 Truststore (if required)
-func generateAllKeystores(keystoreDir string, p12TruststoreRequired bool, nativeTLSHA bool) (TLSStore, error) {
+func generateAllKeystores(keystoreDir string, password string, p12TruststoreRequired bool, nativeTLSHA bool) (TLSStore, error) {
 
 	var cmsKeystore, p12Truststore KeyStoreData
 
-	// Generate a pasword for use with both the CMS Keystore & PKCS#12 Truststore
-	pw := generateRandomPassword()
-	cmsKeystore.Password = pw
-	p12Truststore.Password = pw
+	// Use the same pasword for both the CMS Keystore & PKCS#12 Truststore
+	cmsKeystore.Password = password
+	p12Truststore.Password = password
 
 	// Create the Keystore directory - if it does not already exist
 	// #nosec G301 - write group permissions are required
@@ -220,7 +243,7 @@ func generateAllKeystores(keystoreDir string, p12TruststoreRequired bool, native
 }
 
 // processKeys processes all keys - adding them to the CMS KeyStore
-func processKeys(tlsStore *TLSStore, keystoreDir string, keyDir string) (string, error) {
+func processKeys(tlsStore *TLSStore, keystoreDir string, keyDir string, trustDir string, log *logger.Logger) (string, error) {
 
 	// Key label - will be set to the label of the first set of keys
 	keyLabel := ""
@@ -237,21 +260,41 @@ func processKeys(tlsStore *TLSStore, keystoreDir string, keyDir string) (string,
 				return "", fmt.Errorf("Key label cannot be set to the Truststore name: %v", keySet.Name())
 			}
 
-			// Process private key (*.key)
-			privateKey, keyPrefix, err := processPrivateKey(keyDir, keySet.Name(), keys)
-			if err != nil {
-				return "", err
-			}
+			var privateKey interface{}
+			var publicCertificate *x509.Certificate
+			var caCertificate []*x509.Certificate
 
-			// If private key does not exist - skip this set of keys
-			if privateKey == nil {
-				continue
-			}
+			p12File := findP12File(keys)
+			if p12File != "" {
+				// Process PKCS#12 keystore (*.p12 or *.pfx) - private key, public certificate & optional CA certificate
+				privateKey, publicCertificate, caCertificate, err = processP12Keys(keyDir, keySet.Name(), p12File, trustDir, &tlsStore.Keystore, &tlsStore.Truststore, log)
+				if err != nil {
+					return "", err
+				}
+			} else {
+				// Process private key (*.key)
+				var keyPrefix string
+				privateKey, keyPrefix, err = processPrivateKey(keyDir, keySet.Name(), keys, log)
+				if err != nil {
+					return "", err
+				}
 
-			// Process certificates (*.crt) - public certificate & optional CA certificate
-			publicCertificate, caCertificate, err := processCertificates(keyDir, keySet.Name(), keyPrefix, keys, &tlsStore.Keystore, &tlsStore.Truststore)
-			if err != nil {
-				return "", err
+				// If private key does not exist - skip this set of keys
+				if privateKey == nil {
+					continue
+				}
+
+				// Validate the private key, public certificate and certificate chain
+				err = validateKeySet(keyDir, keySet.Name(), keyPrefix, privateKey, trustDir, log)
+				if err != nil {
+					return "", err
+				}
+
+				// Process certificates (*.crt) - public certificate & optional CA certificate
+				publicCertificate, caCertificate, err = processCertificates(keyDir, keySet.Name(), keyPrefix, keys, &tlsStore.Keystore, &tlsStore.Truststore)
+				if err != nil {
+					return "", err
+				}
 			}
 
 			// Validate certificates for duplicate Subject DNs
@@ -359,7 +402,7 @@ func processTrustCertificates(tlsStore *TLSStore, trustDir string) error {
 }
 
 // processPrivateKey processes the private key (*.key) from a set of keys
-func processPrivateKey(keyDir string, keySetName string, keys []os.DirEntry) (interface{}, string, error) {
+func processPrivateKey(keyDir string, keySetName string, keys []os.DirEntry, log *logger.Logger) (interface{}, string, error) {
 
 	var privateKey interface{}
 	keyPrefix := ""
@@ -377,13 +420,24 @@ func processPrivateKey(keyDir string, keySetName string, keys []os.DirEntry) (in
 				return nil, "", fmt.Errorf("Failed to decode private key %s: pem.Decode returned nil", filepath.Join(keyDir, keySetName, key.Name()))
 			}
 
+			// Decrypt the private key if it is encrypted
+			der, err := decryptPrivateKey(block, keyDir, keySetName, filepath.Join(keyDir, keySetName, key.Name()), log)
+			if err != nil {
+				return nil, "", err
+			}
+
 			// Check if the private key is PKCS1
-			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			privateKey, err = x509.ParsePKCS1PrivateKey(der)
 			if err != nil {
 				// Check if the private key is PKCS8
-				privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+				privateKey, err = x509.ParsePKCS8PrivateKey(der)
 				if err != nil {
-					return nil, "", fmt.Errorf("Failed to parse private key %s: %v", filepath.Join(keyDir, keySetName, key.Name()), err)
+					// Check if the private key is an EC private key
+					var ecErr error
+					privateKey, ecErr = x509.ParseECPrivateKey(der)
+					if ecErr != nil {
+						return nil, "", fmt.Errorf("Failed to parse private key %s: %v", filepath.Join(keyDir, keySetName, key.Name()), err)
+					}
 				}
 			}
 			keyPrefix = key.Name()[0 : len(key.Name())-len(filepath.Ext(key.Name()))]
@@ -462,6 +516,55 @@ func processCertificates(keyDir string, keySetName, keyPrefix string, keys []os.
 	return publicCertificate, caCertificate, nil
 }
 
+// processP12Keys processes the private key, public certificate & optional CA certificates from a PKCS#12 keystore
+// (*.p12 or *.pfx) in a set of keys
+func processP12Keys(keyDir string, keySetName string, p12File string, trustDir string, cmsKeystore, p12Truststore *KeyStoreData, log *logger.Logger) (interface{}, *x509.Certificate, []*x509.Certificate, error) {
+
+	privateKey, publicCertificate, caCertificate, err := loadP12KeySet(keyDir, keySetName, p12File)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	file := filepath.Join(keyDir, keySetName, p12File)
+	if privateKey == nil || publicCertificate == nil {
+		return nil, nil, nil, fmt.Errorf("Failed to find private key and public certificate in PKCS#12 keystore %s", file)
+	}
+
+	// Validate the private key, public certificate and certificate chain
+	caCertificateFiles := make([]certificateFile, len(caCertificate))
+	for i, certificate := range caCertificate {
+		caCertificateFiles[i] = certificateFile{certificate, file}
+	}
+	err = validateKeys(keySetName, file, privateKey, certificateFile{publicCertificate, file}, caCertificateFiles, trustDir, log)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Add to known certificates for the CMS Keystore
+	err = addToKnownCertificates(&pem.Block{Type: "CERTIFICATE", Bytes: publicCertificate.Raw}, cmsKeystore, false)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to add to known certificates for CMS Keystore")
+	}
+	for _, certificate := range caCertificate {
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}
+
+		// Add to known certificates for the CMS Keystore
+		err = addToKnownCertificates(block, cmsKeystore, false)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Failed to add to known certificates for CMS Keystore")
+		}
+
+		if p12Truststore.Keystore != nil {
+			// Add to known certificates for the PKCS#12 Truststore
+			err = addToKnownCertificates(block, p12Truststore, true)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("Failed to add to known certificates for PKCS#12 Truststore")
+			}
+		}
+	}
+
+	return privateKey, publicCertificate, caCertificate, nil
+}
+
 // relabelCertificate sets a new label for a certificate in the CMS Keystore
 func relabelCertificate(newLabel string, cmsKeystore *KeyStoreData) error {
 
@@ -639,8 +742,8 @@ func writeCertificatesToFile(file string, certificates []*pem.Block) error {
 	return nil
 }
 
-// Search the specified directory for .key and .crt files.
-// Return true if at least one .key or .crt file is found else false
+// Search the specified directory for .key, .p12, .pfx and .crt files.
+// Return true if at least one such file is found else false
 func haveKeysAndCerts(keyDir string) bool {
 	fileList, err := os.ReadDir(keyDir)
 	if err == nil && len(fileList) > 0 {
@@ -649,7 +752,7 @@ func haveKeysAndCerts(keyDir string) bool {
 			// Do a listing of the subdirectory and then search for .key and .cert files
 			keys, _ := os.ReadDir(filepath.Join(keyDir, fileInfo.Name()))
 			for _, key := range keys {
-				if strings.HasSuffix(key.Name(), ".key") || strings.HasSuffix(key.Name(), ".crt") {
+				if isKeyFile(key.Name()) || strings.HasSuffix(key.Name(), ".crt") {
 					// We found at least one key/crt file.
 					return true
 				}
@@ -664,8 +767,7 @@ func haveKeysAndCerts(keyDir string) bool {
 func validateCertificates(personalCert *x509.Certificate, caCertificates []*x509.Certificate) error {
 	// Check if we have been asked to override certificate validation by setting
 	// MQ_ENABLE_CERT_VALIDATION to false
-	enableValidation, enableValidationSet := os.LookupEnv("MQ_ENABLE_CERT_VALIDATION")
-	if !enableValidationSet || (enableValidationSet && !strings.EqualFold(strings.Trim(enableValidation, ""), "false")) {
+	if validationEnabled() {
 		for _, caCert := range caCertificates {
 			if strings.EqualFold(personalCert.Subject.String(), caCert.Subject.String()) {
 				return fmt.Errorf("Error: The Subject DN of the Issuer Certificate and the Queue Manager are same")
@@ -0,0 +1,209 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// channelsFileName is the name of the file in a set of keys listing the channels which use its certificate
+const channelsFileName = "channels"
+
+// defaultChannelType is the type of a channel, if it isn't given
+const defaultChannelType = "SVRCONN"
+
+// channelRegexp matches a channel name, optionally followed by the channel type in brackets
+var channelRegexp = regexp.MustCompile(`^([A-Za-z0-9._/%]{1,20})(?:\(([A-Za-z]+)\))?$`)
+
+// channelTypes are the types of channel which a certificate label can be set for
+var channelTypes = map[string]bool{
+	"SDR":      true,
+	"SVR":      true,
+	"RCVR":     true,
+	"RQSTR":    true,
+	"CLUSSDR":  true,
+	"CLUSRCVR": true,
+	"SVRCONN":  true,
+}
+
+// ChannelLabel is the certificate label used by a channel
+type ChannelLabel struct {
+	Channel string
+	Type    string
+	Label   string
+}
+
+// GetChannelLabels returns the certificate label to use for each channel, from the channels file in each set of
+// keys, and from MQ_TLS_CHANNEL_LABELS.  Each label must be the label of a set of keys in the CMS Keystore.
+func GetChannelLabels(cmsKeystore KeyStoreData) ([]ChannelLabel, error) {
+	return getChannelLabels(keyDirDefault, cmsKeystore)
+}
+
+// getChannelLabels returns the certificate label to use for each channel, from the channels file in each set of
+// keys in the key directory, and from MQ_TLS_CHANNEL_LABELS
+func getChannelLabels(keyDir string, cmsKeystore KeyStoreData) ([]ChannelLabel, error) {
+	channels := map[string]ChannelLabel{}
+	add := func(label string, entries []string, source string) error {
+		for _, entry := range entries {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			channel, err := parseChannel(entry, label)
+			if err != nil {
+				return fmt.Errorf("Invalid channel in %s: %v", source, err)
+			}
+			if existing, ok := channels[channel.Channel]; ok && existing.Label != label {
+				return fmt.Errorf("Channel %s in %s is already set to use certificate label %s", channel.Channel, source, existing.Label)
+			}
+			channels[channel.Channel] = channel
+		}
+		return nil
+	}
+
+	keyList, _ := os.ReadDir(keyDir)
+	for _, keySet := range keyList {
+		file := filepath.Join(keyDir, keySet.Name(), channelsFileName)
+		// #nosec G304 - filename variable is derived from contents of 'keyDir' which is a defined constant
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Failed to read file %s: %v", file, err)
+		}
+		err = add(keySet.Name(), parseChannelsFile(string(buf)), file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// MQ_TLS_CHANNEL_LABELS has the format label:channel,channel;label:channel
+	mappings := strings.TrimSpace(os.Getenv("MQ_TLS_CHANNEL_LABELS"))
+	if mappings != "" {
+		for _, mapping := range strings.Split(mappings, ";") {
+			if strings.TrimSpace(mapping) == "" {
+				continue
+			}
+			label, list, found := strings.Cut(mapping, ":")
+			if !found || strings.TrimSpace(label) == "" {
+				return nil, fmt.Errorf("Invalid value for MQ_TLS_CHANNEL_LABELS: %v.  Each mapping must have the format label:channel,channel", mapping)
+			}
+			err := add(strings.TrimSpace(label), strings.Split(list, ","), "MQ_TLS_CHANNEL_LABELS")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	channelLabels := []ChannelLabel{}
+	for _, channel := range channels {
+		if !hasKeyLabel(cmsKeystore, channel.Label) {
+			return nil, fmt.Errorf("Certificate label %s for channel %s was not found.  The label must be the name of a directory in %s containing a private key", channel.Label, channel.Channel, keyDir)
+		}
+		channelLabels = append(channelLabels, channel)
+	}
+	sort.Slice(channelLabels, func(i, j int) bool {
+		return channelLabels[i].Channel < channelLabels[j].Channel
+	})
+	return channelLabels, nil
+}
+
+// parseChannelsFile returns the channel entries in the contents of a channels file.  Entries are separated by new
+// lines or commas, and lines starting with a hash are comments.
+func parseChannelsFile(contents string) []string {
+	entries := []string{}
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		entries = append(entries, strings.Split(line, ",")...)
+	}
+	return entries
+}
+
+// parseChannel parses a channel name, optionally followed by the channel type in brackets, for example
+// TO.QM2(SDR).  The type defaults to SVRCONN.
+func parseChannel(entry string, label string) (ChannelLabel, error) {
+	match := channelRegexp.FindStringSubmatch(entry)
+	if match == nil {
+		return ChannelLabel{}, fmt.Errorf("%s is not a valid channel name", entry)
+	}
+	channelType := strings.ToUpper(match[2])
+	if channelType == "" {
+		channelType = defaultChannelType
+	}
+	if !channelTypes[channelType] {
+		return ChannelLabel{}, fmt.Errorf("%s is not a channel type which can have a certificate label, for channel %s", channelType, match[1])
+	}
+	return ChannelLabel{Channel: match[1], Type: channelType, Label: label}, nil
+}
+
+// checkChannelDefinitions returns a warning for each channel which is defined in an MQSC file that runs after the
+// MQSC file setting the certificate labels.  MQSC files in the directory are run in order of their names, and setting
+// the certificate label fails if the channel hasn't been defined yet.
+func checkChannelDefinitions(mqscDir string, mqscFile string, channelLabels []ChannelLabel) []string {
+	warnings := []string{}
+	if len(channelLabels) == 0 {
+		return warnings
+	}
+	files, _ := os.ReadDir(mqscDir)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".mqsc") || file.Name() <= mqscFile {
+			continue
+		}
+		// #nosec G304 - filename variable is derived from contents of 'mqscDir' which is a defined constant
+		buf, err := os.ReadFile(filepath.Join(mqscDir, file.Name()))
+		if err != nil {
+			continue
+		}
+		for _, channel := range channelLabels {
+			define := regexp.MustCompile(`(?i)\bDEFINE\s+(CHANNEL|CHL)\s*\(\s*'?` + regexp.QuoteMeta(channel.Channel) + `'?\s*\)`)
+			if define.Match(buf) {
+				warnings = append(warnings, fmt.Sprintf("Channel %s is defined in %s, which runs after %s, so setting its certificate label %s fails when the queue manager first starts.  Define the channel in an MQSC file which sorts before %s", channel.Channel, file.Name(), mqscFile, channel.Label, mqscFile))
+			}
+		}
+	}
+	return warnings
+}
+
+// hasKeyLabel returns true if a set of keys with the given label has been added to the CMS Keystore
+func hasKeyLabel(cmsKeystore KeyStoreData, label string) bool {
+	for _, keyLabel := range cmsKeystore.KeyLabels {
+		if keyLabel == label {
+			return true
+		}
+	}
+	return false
+}
+
+// selectDefaultLabel returns the label set by MQ_TLS_DEFAULT_LABEL if it is set, or otherwise the label of the
+// first set of keys
+func selectDefaultLabel(firstLabel string, cmsKeystore KeyStoreData) (string, error) {
+	label := strings.TrimSpace(os.Getenv("MQ_TLS_DEFAULT_LABEL"))
+	if label == "" {
+		return firstLabel, nil
+	}
+	if !hasKeyLabel(cmsKeystore, label) {
+		return "", fmt.Errorf("Invalid value for MQ_TLS_DEFAULT_LABEL: %v.  The label must be the name of a directory in %s containing a private key", label, keyDirDefault)
+	}
+	return label, nil
+}
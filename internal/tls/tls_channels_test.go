@@ -0,0 +1,215 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var parseChannelTests = []struct {
+	entry     string
+	expected  ChannelLabel
+	expectErr bool
+}{
+	{"APP.SVRCONN", ChannelLabel{"APP.SVRCONN", "SVRCONN", "mykey"}, false},
+	{"TO.QM2(SDR)", ChannelLabel{"TO.QM2", "SDR", "mykey"}, false},
+	{"TO.QM2(sdr)", ChannelLabel{"TO.QM2", "SDR", "mykey"}, false},
+	{"CLUS.QM1(CLUSRCVR)", ChannelLabel{"CLUS.QM1", "CLUSRCVR", "mykey"}, false},
+	{"a/b%c_d.e", ChannelLabel{"a/b%c_d.e", "SVRCONN", "mykey"}, false},
+	{"ABCDEFGHIJKLMNOPQRST", ChannelLabel{"ABCDEFGHIJKLMNOPQRST", "SVRCONN", "mykey"}, false},
+	{"ABCDEFGHIJKLMNOPQRSTU", ChannelLabel{}, true},
+	{"APP.SVRCONN(CLNTCONN)", ChannelLabel{}, true},
+	{"APP.SVRCONN(AMQP)", ChannelLabel{}, true},
+	{"APP.SVRCONN()", ChannelLabel{}, true},
+	{"APP.SVRCONN(SDR", ChannelLabel{}, true},
+	{"APP SVRCONN", ChannelLabel{}, true},
+	{"(SDR)", ChannelLabel{}, true},
+}
+
+func TestParseChannel(t *testing.T) {
+	for _, test := range parseChannelTests {
+		t.Run(test.entry, func(t *testing.T) {
+			channel, err := parseChannel(test.entry, "mykey")
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error parsing %q; got %+v", test.entry, channel)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if channel != test.expected {
+				t.Errorf("Expected %+v; got %+v", test.expected, channel)
+			}
+		})
+	}
+}
+
+func TestParseChannelsFile(t *testing.T) {
+	contents := "# Channels using mykey\n\nAPP.SVRCONN\n  # Indented comment\nTO.QM2(SDR), TO.QM3(SDR)\r\n\n"
+	expected := []string{"", "APP.SVRCONN", "TO.QM2(SDR)", " TO.QM3(SDR)\r", "", ""}
+	entries := parseChannelsFile(contents)
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %q; got %q", expected, entries)
+	}
+}
+
+// newTestChannelsKeyDir creates a key directory with a channels file for each key set
+func newTestChannelsKeyDir(t *testing.T, channelsFiles map[string]string) string {
+	t.Helper()
+	keyDir := t.TempDir()
+	for keySetName, contents := range channelsFiles {
+		err := os.Mkdir(filepath.Join(keyDir, keySetName), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(keyDir, keySetName, channelsFileName), []byte(contents), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keyDir
+}
+
+func TestGetChannelLabels(t *testing.T) {
+	cmsKeystore := KeyStoreData{KeyLabels: []string{"default", "mykey", "otherkey"}}
+	var channelLabelTests = []struct {
+		name      string
+		files     map[string]string
+		env       string
+		expected  []ChannelLabel
+		expectErr bool
+	}{
+		{
+			name:     "None",
+			expected: []ChannelLabel{},
+		},
+		{
+			name:  "ChannelsFile",
+			files: map[string]string{"mykey": "# Comment\n\nTO.QM2(SDR)\r\nAPP.SVRCONN, ADMIN.SVRCONN\n"},
+			expected: []ChannelLabel{
+				{"ADMIN.SVRCONN", "SVRCONN", "mykey"},
+				{"APP.SVRCONN", "SVRCONN", "mykey"},
+				{"TO.QM2", "SDR", "mykey"},
+			},
+		},
+		{
+			name:  "Environment",
+			env:   " mykey:APP.SVRCONN,TO.QM2(SDR); otherkey:ADMIN.SVRCONN ;",
+			files: map[string]string{"default": "# No channels\n"},
+			expected: []ChannelLabel{
+				{"ADMIN.SVRCONN", "SVRCONN", "otherkey"},
+				{"APP.SVRCONN", "SVRCONN", "mykey"},
+				{"TO.QM2", "SDR", "mykey"},
+			},
+		},
+		{
+			name:     "DuplicateSameLabel",
+			files:    map[string]string{"mykey": "APP.SVRCONN\nAPP.SVRCONN\n"},
+			env:      "mykey:APP.SVRCONN",
+			expected: []ChannelLabel{{"APP.SVRCONN", "SVRCONN", "mykey"}},
+		},
+		{
+			name:      "DuplicateDifferentLabels",
+			files:     map[string]string{"mykey": "APP.SVRCONN\n", "otherkey": "APP.SVRCONN\n"},
+			expectErr: true,
+		},
+		{
+			name:      "DuplicateInEnvironment",
+			files:     map[string]string{"mykey": "APP.SVRCONN\n"},
+			env:       "otherkey:APP.SVRCONN",
+			expectErr: true,
+		},
+		{
+			name:      "BadChannelType",
+			files:     map[string]string{"mykey": "APP.SVRCONN(CLNTCONN)\n"},
+			expectErr: true,
+		},
+		{
+			name:      "BadChannelName",
+			files:     map[string]string{"mykey": "APP SVRCONN\n"},
+			expectErr: true,
+		},
+		{
+			name:      "UnknownLabel",
+			files:     map[string]string{"nokey": "APP.SVRCONN\n"},
+			expectErr: true,
+		},
+		{
+			name:      "EnvironmentMissingLabel",
+			env:       "APP.SVRCONN",
+			expectErr: true,
+		},
+		{
+			name:      "EnvironmentEmptyLabel",
+			env:       ":APP.SVRCONN",
+			expectErr: true,
+		},
+	}
+	for _, test := range channelLabelTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("MQ_TLS_CHANNEL_LABELS", test.env)
+			keyDir := newTestChannelsKeyDir(t, test.files)
+			channelLabels, err := getChannelLabels(keyDir, cmsKeystore)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error; got %+v", channelLabels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(channelLabels, test.expected) {
+				t.Errorf("Expected %+v; got %+v", test.expected, channelLabels)
+			}
+		})
+	}
+}
+
+func TestCheckChannelDefinitions(t *testing.T) {
+	mqscDir := t.TempDir()
+	files := map[string]string{
+		"10-early.mqsc":   "DEFINE CHANNEL(EARLY.SVRCONN) CHLTYPE(SVRCONN)\n",
+		"20-late.mqsc":    "define chl('LATE.SVRCONN') chltype(SVRCONN)\nALTER CHANNEL(EARLY.SVRCONN) CHLTYPE(SVRCONN)\n",
+		"30-late.txt":     "DEFINE CHANNEL(TEXT.SVRCONN) CHLTYPE(SVRCONN)\n",
+		"40-prefix.mqsc":  "DEFINE CHANNEL(LATE.SVRCONN.2) CHLTYPE(SVRCONN)\n",
+		"15-tls.mqsc":     "ALTER CHANNEL('LATE.SVRCONN') CHLTYPE(SVRCONN) CERTLABL('mykey')\n",
+		"15-tls.mqsc.tpl": "DEFINE CHANNEL(TEMPLATE.SVRCONN) CHLTYPE(SVRCONN)\n",
+	}
+	for file, contents := range files {
+		err := os.WriteFile(filepath.Join(mqscDir, file), []byte(contents), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	channelLabels := []ChannelLabel{
+		{"EARLY.SVRCONN", "SVRCONN", "mykey"},
+		{"LATE.SVRCONN", "SVRCONN", "mykey"},
+		{"TEXT.SVRCONN", "SVRCONN", "mykey"},
+		{"TEMPLATE.SVRCONN", "SVRCONN", "mykey"},
+		{"UNDEFINED.SVRCONN", "SVRCONN", "mykey"},
+	}
+
+	warnings := checkChannelDefinitions(mqscDir, "15-tls.mqsc", channelLabels)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected a warning for LATE.SVRCONN only; got %q", warnings)
+	}
+}
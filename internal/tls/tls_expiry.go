@@ -0,0 +1,175 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	pkcs "software.sslmate.com/src/go-pkcs12"
+)
+
+// Names of the keystores which certificates are reported for
+const (
+	StoreQueueManager = "qmgr"
+	StoreNativeHA     = "nativeha"
+	StoreWeb          = "web"
+	StoreMetrics      = "metrics"
+)
+
+// CertificateInfo describes a certificate in one of the keystores
+type CertificateInfo struct {
+	Store    string
+	Label    string
+	Subject  string
+	NotAfter time.Time
+}
+
+// ListCertificates returns the certificates in the queue manager and native HA keystores, the keys and trust
+// certificates for the metrics server, and the web server keystore and truststore if the name of the web keystore
+// is given.  Certificates in the queue manager and native HA keystores, and for the metrics server, are labelled
+// with the name of their set of keys.  Certificates which can't be read
+// are skipped, and the first error is returned along with the certificates which could be read.
+func ListCertificates(webKeystore string, password string) ([]CertificateInfo, error) {
+	var firstErr error
+	certificates := []CertificateInfo{}
+	add := func(found []CertificateInfo, err error) {
+		certificates = append(certificates, found...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	add(pemCertificates(keyDirDefault, StoreQueueManager))
+	add(pemCertificates(trustDirDefault, StoreQueueManager))
+	add(pemCertificates(keyDirHA, StoreNativeHA))
+	add(pemCertificates(metricsKeyDir, StoreMetrics))
+	add(pemCertificates(metricsTrustDir, StoreMetrics))
+	if webKeystore != "" {
+		add(p12Certificates(filepath.Join(keystoreDirDefault, webKeystore), strings.TrimSuffix(webKeystore, filepath.Ext(webKeystore)), password))
+		add(p12Certificates(filepath.Join(keystoreDirDefault, p12TruststoreName), "trust", password))
+	}
+	return uniqueCertificates(certificates), firstErr
+}
+
+// pemCertificates returns the certificates (*.crt), and the certificates in PKCS#12 keystores (*.p12 or *.pfx),
+// in each set of keys in a directory
+func pemCertificates(dir string, store string) ([]CertificateInfo, error) {
+	files, err := keyAndCertFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	certificates := []CertificateInfo{}
+	for _, file := range files {
+		if isP12File(file) {
+			label := filepath.Base(filepath.Dir(file))
+			_, publicCertificate, caCertificates, err := loadP12KeySet(dir, label, filepath.Base(file))
+			if err != nil {
+				return certificates, err
+			}
+			for _, certificate := range append([]*x509.Certificate{publicCertificate}, caCertificates...) {
+				certificates = append(certificates, newCertificateInfo(store, label, certificate))
+			}
+			continue
+		}
+		if !strings.HasSuffix(file, ".crt") {
+			continue
+		}
+		// #nosec G304 - filename variable is derived from contents of a directory which is a defined constant
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return certificates, fmt.Errorf("Failed to read file %s: %v", file, err)
+		}
+		label := filepath.Base(filepath.Dir(file))
+		for {
+			var block *pem.Block
+			block, buf = pem.Decode(buf)
+			if block == nil {
+				break
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return certificates, fmt.Errorf("Failed to parse certificate in %s: %v", file, err)
+			}
+			certificates = append(certificates, newCertificateInfo(store, label, certificate))
+		}
+	}
+	return certificates, nil
+}
+
+// p12Certificates returns the certificates in a PKCS#12 keystore
+func p12Certificates(file string, label string, password string) ([]CertificateInfo, error) {
+	// #nosec G304 - filename variable is derived from a defined constant
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to read keystore %s: %v", file, err)
+	}
+	blocks, err := pkcs.ToPEM(buf, password)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode keystore %s: %v", file, err)
+	}
+	certificates := []CertificateInfo{}
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certificates, fmt.Errorf("Failed to parse certificate in %s: %v", file, err)
+		}
+		certificates = append(certificates, newCertificateInfo(StoreWeb, label, certificate))
+	}
+	return certificates, nil
+}
+
+func newCertificateInfo(store string, label string, certificate *x509.Certificate) CertificateInfo {
+	return CertificateInfo{
+		Store:    store,
+		Label:    label,
+		Subject:  certificate.Subject.String(),
+		NotAfter: certificate.NotAfter,
+	}
+}
+
+// uniqueCertificates removes certificates with the same store, label and subject, keeping the one which
+// expires first, so that each certificate is only reported once
+func uniqueCertificates(certificates []CertificateInfo) []CertificateInfo {
+	unique := []CertificateInfo{}
+	index := make(map[string]int)
+	for _, certificate := range certificates {
+		key := certificate.Store + "\x00" + certificate.Label + "\x00" + certificate.Subject
+		if i, ok := index[key]; ok {
+			if certificate.NotAfter.Before(unique[i].NotAfter) {
+				unique[i] = certificate
+			}
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, certificate)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].NotAfter.Before(unique[j].NotAfter)
+	})
+	return unique
+}
@@ -0,0 +1,277 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+	"golang.org/x/crypto/pbkdf2"
+	pkcs "software.sslmate.com/src/go-pkcs12"
+)
+
+// keyPasswordFileName is the name of the file in a set of keys containing the password for an encrypted
+// private key or PKCS#12 keystore
+const keyPasswordFileName = "password"
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHmacWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHmacWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHmacWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHmacWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// encryptedPrivateKeyInfo is an encrypted PKCS#8 private key, as defined in RFC 5208
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of the PBES2 encryption scheme, as defined in RFC 8018
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of the PBKDF2 key derivation function, as defined in RFC 8018
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// isP12File returns true if a file in a set of keys is a PKCS#12 keystore
+func isP12File(name string) bool {
+	return strings.HasSuffix(name, ".p12") || strings.HasSuffix(name, ".pfx")
+}
+
+// isKeyFile returns true if a file in a set of keys contains a private key
+func isKeyFile(name string) bool {
+	return strings.HasSuffix(name, ".key") || isP12File(name)
+}
+
+// getKeyPassword returns the password for the encrypted private key or PKCS#12 keystore in a set of keys.  The
+// password is read from the password file in the set of keys, or else from the file named by
+// MQ_TLS_KEY_PASSWORD_FILE, such as a mounted secret.  False is returned if no password has been supplied.
+func getKeyPassword(keyDir string, keySetName string) (string, bool, error) {
+	file := filepath.Join(keyDir, keySetName, keyPasswordFileName)
+	if _, err := os.Stat(file); err != nil {
+		file = os.Getenv("MQ_TLS_KEY_PASSWORD_FILE")
+		if file == "" {
+			return "", false, nil
+		}
+	}
+	// #nosec G304 - filename variable is a defined constant in a set of keys, or is set by the administrator
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("Failed to read password file %s: %v", file, err)
+	}
+	return strings.TrimRight(string(buf), "\r\n"), true, nil
+}
+
+// findP12File returns the name of the PKCS#12 keystore in a set of keys, if there is no private key (*.key)
+func findP12File(keys []os.DirEntry) string {
+	p12Files := []string{}
+	for _, key := range keys {
+		if strings.HasSuffix(key.Name(), ".key") {
+			return ""
+		}
+		if isP12File(key.Name()) {
+			p12Files = append(p12Files, key.Name())
+		}
+	}
+	if len(p12Files) == 0 {
+		return ""
+	}
+	sort.Strings(p12Files)
+	return p12Files[0]
+}
+
+// loadP12KeySet reads the private key, public certificate and CA certificates from a PKCS#12 keystore in a set
+// of keys
+func loadP12KeySet(keyDir string, keySetName string, p12File string) (interface{}, *x509.Certificate, []*x509.Certificate, error) {
+	file := filepath.Join(keyDir, keySetName, p12File)
+	password, _, err := getKeyPassword(keyDir, keySetName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// #nosec G304 - filename variable is derived from contents of 'keyDir' which is a defined constant
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to read PKCS#12 keystore %s: %v", file, err)
+	}
+	privateKey, publicCertificate, caCertificates, err := pkcs.DecodeChain(buf, password)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to decode PKCS#12 keystore %s: %v", file, err)
+	}
+	return privateKey, publicCertificate, caCertificates, nil
+}
+
+// decryptPrivateKey decrypts a PEM encoded private key if it is encrypted, using the password for its set of
+// keys.  Encrypted PKCS#8 keys, and keys encrypted using the legacy OpenSSL PEM encryption, are supported.  A
+// warning is logged for keys using the legacy encryption, if a logger is supplied.
+func decryptPrivateKey(block *pem.Block, keyDir string, keySetName string, file string, log *logger.Logger) ([]byte, error) {
+	// Legacy PEM encryption is insecure, and is only supported for existing keys
+	legacy := x509.IsEncryptedPEMBlock(block)
+	if block.Type != "ENCRYPTED PRIVATE KEY" && !legacy {
+		return block.Bytes, nil
+	}
+	password, found, err := getKeyPassword(keyDir, keySetName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Failed to decrypt private key %s: no password was found in %s or MQ_TLS_KEY_PASSWORD_FILE", file, filepath.Join(keyDir, keySetName, keyPasswordFileName))
+	}
+	var der []byte
+	if legacy {
+		if log != nil {
+			log.Warnf("Private key %s uses the legacy PEM encryption, which is insecure and deprecated.  Convert it to an encrypted PKCS#8 key, for example using 'openssl pkcs8 -topk8 -v2 aes-256-cbc'", file)
+		}
+		der, err = decryptLegacyPEMBlock(block, []byte(password))
+	} else {
+		der, err = decryptPKCS8(block.Bytes, []byte(password))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt private key %s: %v", file, err)
+	}
+	return der, nil
+}
+
+// decryptLegacyPEMBlock decrypts a PKCS#1 or EC private key encrypted using the legacy OpenSSL PEM encryption.  An
+// incorrect password is not always detected when the padding is removed, so the decrypted key is parsed as well.
+func decryptLegacyPEMBlock(block *pem.Block, password []byte) ([]byte, error) {
+	der, err := x509.DecryptPEMBlock(block, password)
+	if err != nil {
+		if err == x509.IncorrectPasswordError {
+			return nil, fmt.Errorf("incorrect password")
+		}
+		return nil, err
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return der, nil
+	}
+	if _, err := x509.ParseECPrivateKey(der); err == nil {
+		return der, nil
+	}
+	return nil, fmt.Errorf("incorrect password")
+}
+
+// decryptPKCS8 decrypts an encrypted PKCS#8 private key, which uses the PBES2 scheme with PBKDF2, and AES or
+// Triple DES in CBC mode
+func decryptPKCS8(der []byte, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted PKCS#8 key: %v", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm %v, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %v", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function %v, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, fmt.Errorf("invalid PBKDF2 parameters: %v", err)
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHmacWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHmacWithSHA256):
+		prf = sha256.New
+	case kdfParams.PRF.Algorithm.Equal(oidHmacWithSHA384):
+		prf = sha512.New384
+	case kdfParams.PRF.Algorithm.Equal(oidHmacWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudo-random function %v", kdfParams.PRF.Algorithm)
+	}
+
+	var keyLength int
+	var newCipher func(key []byte) (cipher.Block, error)
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme %v", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("invalid encryption scheme parameters: %v", err)
+	}
+
+	key := pbkdf2.Key(password, kdfParams.Salt, kdfParams.IterationCount, keyLength, prf)
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// Remove the PKCS#7 padding, which is only valid if the password is correct
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, fmt.Errorf("incorrect password")
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("incorrect password")
+		}
+	}
+	// The padding can be valid by chance, so check that the decrypted key can be parsed
+	decrypted = decrypted[:len(decrypted)-padding]
+	if _, err := x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+		return nil, fmt.Errorf("incorrect password")
+	}
+	return decrypted, nil
+}
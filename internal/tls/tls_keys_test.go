@@ -0,0 +1,306 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// The files in testdata were generated using OpenSSL, with the password "passw0rd":
+//
+//	openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256 -out ec.key
+//	openssl req -new -x509 -key ec.key -subj "/CN=server" -days 36500 -out ec.crt
+//	openssl pkcs8 -topk8 -v2 aes-256-cbc -v2prf hmacWithSHA1 -in ec.key -out ec-sha1.key
+//	openssl pkcs8 -topk8 -v2 aes-256-cbc -v2prf hmacWithSHA256 -in ec.key -out ec-sha256.key
+//	openssl pkcs8 -topk8 -v2 des3 -v2prf hmacWithSHA256 -in ec.key -out ec-des3.key
+//	openssl pkcs8 -topk8 -v1 PBE-SHA1-3DES -in ec.key -out ec-pbes1.key
+//	openssl ec -in ec.key -aes256 -out ec-legacy.key
+//	openssl genrsa -traditional -aes256 -out rsa-legacy.key 2048
+//	openssl pkcs12 -export -inkey ec.key -in ec.crt -out ec.p12
+//	openssl pkcs12 -export -nocerts -inkey ec.key -out ec-nocert.p12
+//	openssl pkcs12 -export -nokeys -in ec.crt -out ec-nokey.p12
+const testKeyPassword = "passw0rd"
+
+// readTestPEM returns the first PEM block in a file in testdata
+func readTestPEM(t *testing.T, name string) *pem.Block {
+	t.Helper()
+	buf, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		t.Fatalf("Failed to decode %s", name)
+	}
+	return block
+}
+
+// newTestKeySet creates a key directory containing a set of keys, with the specified files from testdata, and a
+// password file if the password is not empty
+func newTestKeySet(t *testing.T, keySetName string, password string, files ...string) string {
+	t.Helper()
+	keyDir := t.TempDir()
+	keySetDir := filepath.Join(keyDir, keySetName)
+	err := os.Mkdir(keySetDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		buf, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(keySetDir, file), buf, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if password != "" {
+		err = os.WriteFile(filepath.Join(keySetDir, keyPasswordFileName), []byte(password+"\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keyDir
+}
+
+var decryptPKCS8Tests = []struct {
+	file     string
+	password string
+	valid    bool
+}{
+	{"ec-sha1.key", testKeyPassword, true},
+	{"ec-sha256.key", testKeyPassword, true},
+	{"ec-des3.key", testKeyPassword, true},
+	{"ec-sha1.key", "wrong", false},
+	{"ec-sha256.key", "wrong", false},
+	{"ec-des3.key", "wrong", false},
+	{"ec-sha256.key", "", false},
+	{"ec-pbes1.key", testKeyPassword, false},
+}
+
+func TestDecryptPKCS8(t *testing.T) {
+	expected := readTestPEM(t, "ec.key").Bytes
+	for _, test := range decryptPKCS8Tests {
+		t.Run(test.file+"/"+test.password, func(t *testing.T) {
+			block := readTestPEM(t, test.file)
+			if block.Type != "ENCRYPTED PRIVATE KEY" {
+				t.Fatalf("Expected an encrypted PKCS#8 key in %s; got %s", test.file, block.Type)
+			}
+			der, err := decryptPKCS8(block.Bytes, []byte(test.password))
+			if !test.valid {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(der, expected) {
+				t.Error("Decrypted key does not match the unencrypted key")
+			}
+		})
+	}
+}
+
+func TestDecryptPKCS8_WrongPasswordMessage(t *testing.T) {
+	_, err := decryptPKCS8(readTestPEM(t, "ec-sha256.key").Bytes, []byte("wrong"))
+	if err == nil || err.Error() != "incorrect password" {
+		t.Errorf("Expected incorrect password error; got %v", err)
+	}
+}
+
+var decryptLegacyTests = []struct {
+	file     string
+	password string
+	valid    bool
+}{
+	{"ec-legacy.key", testKeyPassword, true},
+	{"rsa-legacy.key", testKeyPassword, true},
+	{"ec-legacy.key", "wrong", false},
+	{"rsa-legacy.key", "wrong", false},
+}
+
+func TestDecryptPrivateKey_Legacy(t *testing.T) {
+	for _, test := range decryptLegacyTests {
+		t.Run(test.file+"/"+test.password, func(t *testing.T) {
+			keyDir := newTestKeySet(t, "default", test.password, test.file)
+			buf := new(bytes.Buffer)
+			log, err := logger.NewLogger(buf, false, false, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, err := decryptPrivateKey(readTestPEM(t, test.file), keyDir, "default", test.file, log)
+			if !strings.Contains(buf.String(), "legacy PEM encryption") {
+				t.Errorf("Expected a deprecation warning; got %q", buf.String())
+			}
+			if !test.valid {
+				if err == nil || !strings.Contains(err.Error(), "incorrect password") {
+					t.Errorf("Expected incorrect password error; got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := x509.ParsePKCS1PrivateKey(der); err != nil {
+				if _, err := x509.ParseECPrivateKey(der); err != nil {
+					t.Errorf("Failed to parse decrypted key: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptPrivateKey_NoPassword(t *testing.T) {
+	t.Setenv("MQ_TLS_KEY_PASSWORD_FILE", "")
+	keyDir := newTestKeySet(t, "default", "", "ec-sha256.key")
+	_, err := decryptPrivateKey(readTestPEM(t, "ec-sha256.key"), keyDir, "default", "ec-sha256.key", nil)
+	if err == nil {
+		t.Error("Expected an error decrypting a key with no password")
+	}
+}
+
+func TestDecryptPrivateKey_NotEncrypted(t *testing.T) {
+	block := readTestPEM(t, "ec.key")
+	der, err := decryptPrivateKey(block, t.TempDir(), "default", "ec.key", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Equal(der, block.Bytes) {
+		t.Error("Expected an unencrypted key to be returned unchanged")
+	}
+}
+
+func TestGetKeyPassword(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	err := os.WriteFile(secretFile, []byte("fromsecret\r\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keyPasswordTests = []struct {
+		name      string
+		keySet    string
+		envFile   string
+		expected  string
+		found     bool
+		expectErr bool
+	}{
+		{"KeySetFile", "fromkeyset", "", "fromkeyset", true, false},
+		{"KeySetFileBeforeEnvironment", "fromkeyset", secretFile, "fromkeyset", true, false},
+		{"EnvironmentFile", "", secretFile, "fromsecret", true, false},
+		{"NotSupplied", "", "", "", false, false},
+		{"MissingEnvironmentFile", "", filepath.Join(t.TempDir(), "missing"), "", false, true},
+	}
+	for _, test := range keyPasswordTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("MQ_TLS_KEY_PASSWORD_FILE", test.envFile)
+			keyDir := newTestKeySet(t, "default", test.keySet)
+			password, found, err := getKeyPassword(keyDir, "default")
+			if test.expectErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if password != test.expected || found != test.found {
+				t.Errorf("Expected password %q, found=%v; got %q, found=%v", test.expected, test.found, password, found)
+			}
+		})
+	}
+}
+
+var p12KeySetTests = []struct {
+	file     string
+	password string
+	valid    bool
+}{
+	{"ec.p12", testKeyPassword, true},
+	{"ec.p12", "wrong", false},
+	{"ec-nocert.p12", testKeyPassword, false},
+	{"ec-nokey.p12", testKeyPassword, false},
+}
+
+func TestLoadP12KeySet(t *testing.T) {
+	t.Setenv("MQ_TLS_KEY_PASSWORD_FILE", "")
+	for _, test := range p12KeySetTests {
+		t.Run(test.file+"/"+test.password, func(t *testing.T) {
+			keyDir := newTestKeySet(t, "default", test.password, test.file)
+			privateKey, publicCertificate, _, err := loadP12KeySet(keyDir, "default", test.file)
+			if !test.valid {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if privateKey == nil || publicCertificate == nil {
+				t.Fatal("Expected a private key and public certificate")
+			}
+			if publicCertificate.Subject.CommonName != "server" {
+				t.Errorf("Expected public certificate for server; got %v", publicCertificate.Subject)
+			}
+		})
+	}
+}
+
+func TestProcessP12Keys_MissingKeys(t *testing.T) {
+	t.Setenv("MQ_TLS_KEY_PASSWORD_FILE", "")
+	for _, file := range []string{"ec-nocert.p12", "ec-nokey.p12"} {
+		t.Run(file, func(t *testing.T) {
+			keyDir := newTestKeySet(t, "default", testKeyPassword, file)
+			_, _, _, err := processP12Keys(keyDir, "default", file, t.TempDir(), &KeyStoreData{}, &KeyStoreData{}, nil)
+			if err == nil {
+				t.Errorf("Expected an error processing %s", file)
+			}
+		})
+	}
+}
+
+func TestFindP12File(t *testing.T) {
+	keyDir := newTestKeySet(t, "default", "", "ec.p12", "ec-nokey.p12")
+	keys, err := os.ReadDir(filepath.Join(keyDir, "default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p12File := findP12File(keys); p12File != "ec-nokey.p12" {
+		t.Errorf("Expected the first PKCS#12 keystore in order; got %q", p12File)
+	}
+
+	keyDir = newTestKeySet(t, "default", "", "ec.p12", "ec.key")
+	keys, err = os.ReadDir(filepath.Join(keyDir, "default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p12File := findP12File(keys); p12File != "" {
+		t.Errorf("Expected a private key to be used instead of a PKCS#12 keystore; got %q", p12File)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// metricsKeyDir is the location of the keys to use for the metrics server, instead of the default keys
+const metricsKeyDir = "/etc/mqm/metrics/pki/keys"
+
+// metricsTrustDir is the location of the trust certificates to use for verifying metrics clients
+const metricsTrustDir = "/etc/mqm/metrics/pki/trust"
+
+// metricsTLS holds the current TLS configuration for the metrics server, which is replaced when the keys and
+// trust certificates are reloaded
+var metricsTLS struct {
+	sync.Mutex
+	clientAuth bool
+	config     *cryptotls.Config
+}
+
+// ConfigureMetricsTLS returns the TLS configuration for the metrics server
+// - the first set of keys in /etc/mqm/metrics/pki/keys is used if present, otherwise the default keys with the given label are used
+// - if client authentication is required, client certificates are verified using /etc/mqm/metrics/pki/trust if present, otherwise /etc/mqm/pki/trust
+// - each connection uses the configuration from the last call to ReloadMetricsTLS
+func ConfigureMetricsTLS(keyLabel string, clientAuth bool) (*cryptotls.Config, error) {
+
+	config, err := loadMetricsTLSConfig(keyLabel, clientAuth)
+	if err != nil {
+		return nil, err
+	}
+	return setMetricsTLSConfig(config, clientAuth), nil
+}
+
+// setMetricsTLSConfig sets the current TLS configuration for the metrics server, and returns a configuration
+// which uses the current configuration for each connection
+func setMetricsTLSConfig(config *cryptotls.Config, clientAuth bool) *cryptotls.Config {
+	metricsTLS.Lock()
+	defer metricsTLS.Unlock()
+	metricsTLS.clientAuth = clientAuth
+	metricsTLS.config = config
+
+	// The HTTP server requires a certificate, so it is supplied as well as the configuration for each connection
+	return &cryptotls.Config{
+		MinVersion: cryptotls.VersionTLS12,
+		GetCertificate: func(*cryptotls.ClientHelloInfo) (*cryptotls.Certificate, error) {
+			return &currentMetricsTLSConfig().Certificates[0], nil
+		},
+		GetConfigForClient: func(*cryptotls.ClientHelloInfo) (*cryptotls.Config, error) {
+			return currentMetricsTLSConfig(), nil
+		},
+	}
+}
+
+// ReloadMetricsTLS loads the keys and trust certificates for the metrics server again, if it uses TLS.  The
+// previous configuration is still used if they are not valid.
+func ReloadMetricsTLS(keyLabel string) error {
+	metricsTLS.Lock()
+	defer metricsTLS.Unlock()
+	if metricsTLS.config == nil {
+		return nil
+	}
+	config, err := loadMetricsTLSConfig(keyLabel, metricsTLS.clientAuth)
+	if err != nil {
+		return err
+	}
+	metricsTLS.config = config
+	return nil
+}
+
+// currentMetricsTLSConfig returns the current TLS configuration for the metrics server
+func currentMetricsTLSConfig() *cryptotls.Config {
+	metricsTLS.Lock()
+	defer metricsTLS.Unlock()
+	return metricsTLS.config
+}
+
+// loadMetricsTLSConfig loads the keys and trust certificates for the metrics server
+func loadMetricsTLSConfig(keyLabel string, clientAuth bool) (*cryptotls.Config, error) {
+
+	keyDir := metricsKeyDir
+	keySetName := firstKeySet(metricsKeyDir)
+	if keySetName == "" {
+		keyDir = keyDirDefault
+		keySetName = keyLabel
+	}
+	if keySetName == "" {
+		return nil, fmt.Errorf("Failed to find a key for the metrics server in %s or %s", metricsKeyDir, keyDirDefault)
+	}
+
+	certificate, err := loadKeyPair(keyDir, keySetName)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &cryptotls.Config{
+		Certificates: []cryptotls.Certificate{certificate},
+		MinVersion:   cryptotls.VersionTLS12,
+	}
+
+	if clientAuth {
+		trustDir := metricsTrustDir
+		if !haveKeysAndCerts(metricsTrustDir) {
+			trustDir = trustDirDefault
+		}
+		pool, err := loadCertPool(trustDir)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = cryptotls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// firstKeySet returns the name of the first set of keys containing a private key (*.key, *.p12 or *.pfx)
+func firstKeySet(keyDir string) string {
+	keyList, err := os.ReadDir(keyDir)
+	if err != nil {
+		return ""
+	}
+	for _, keySet := range keyList {
+		keys, _ := os.ReadDir(filepath.Join(keyDir, keySet.Name()))
+		for _, key := range keys {
+			if isKeyFile(key.Name()) {
+				return keySet.Name()
+			}
+		}
+	}
+	return ""
+}
+
+// loadKeyPair loads the private key, public certificate and CA certificates from a set of keys, either from a
+// private key (*.key) and certificates (*.crt), or from a PKCS#12 keystore (*.p12 or *.pfx)
+func loadKeyPair(keyDir string, keySetName string) (cryptotls.Certificate, error) {
+
+	keys, err := os.ReadDir(filepath.Join(keyDir, keySetName))
+	if err != nil {
+		return cryptotls.Certificate{}, fmt.Errorf("Failed to read keys from %s: %v", filepath.Join(keyDir, keySetName), err)
+	}
+
+	var privateKey interface{}
+	var publicCertificate *x509.Certificate
+	var caCertificates []*x509.Certificate
+	if p12File := findP12File(keys); p12File != "" {
+		privateKey, publicCertificate, caCertificates, err = loadP12KeySet(keyDir, keySetName, p12File)
+		if err != nil {
+			return cryptotls.Certificate{}, err
+		}
+	} else {
+		var keyPrefix string
+		// No logger is used, because warnings about the default keys are logged when the keystores are built
+		privateKey, keyPrefix, err = processPrivateKey(keyDir, keySetName, keys, nil)
+		if err != nil {
+			return cryptotls.Certificate{}, err
+		}
+		if privateKey == nil {
+			return cryptotls.Certificate{}, fmt.Errorf("Failed to find private key in %s", filepath.Join(keyDir, keySetName))
+		}
+		public, caCertificateFiles, err := readKeySetCertificates(filepath.Join(keyDir, keySetName), keyPrefix)
+		if err != nil {
+			return cryptotls.Certificate{}, err
+		}
+		publicCertificate = public.certificate
+		for _, c := range caCertificateFiles {
+			caCertificates = append(caCertificates, c.certificate)
+		}
+	}
+	if publicCertificate == nil {
+		return cryptotls.Certificate{}, fmt.Errorf("Failed to find public certificate in %s", filepath.Join(keyDir, keySetName))
+	}
+	err = checkPrivateKey(privateKey, filepath.Join(keyDir, keySetName), certificateFile{publicCertificate, filepath.Join(keyDir, keySetName)})
+	if err != nil {
+		return cryptotls.Certificate{}, err
+	}
+
+	// The public certificate must be first, followed by any CA certificates
+	certificate := cryptotls.Certificate{
+		Certificate: [][]byte{publicCertificate.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        publicCertificate,
+	}
+	for _, c := range caCertificates {
+		certificate.Certificate = append(certificate.Certificate, c.Raw)
+	}
+	return certificate, nil
+}
+
+// loadCertPool loads all trust certificates (*.crt) into a certificate pool
+func loadCertPool(trustDir string) (*x509.CertPool, error) {
+
+	pool := x509.NewCertPool()
+	found := false
+
+	trustList, _ := os.ReadDir(trustDir)
+	for _, trustSet := range trustList {
+		keys, _ := os.ReadDir(filepath.Join(trustDir, trustSet.Name()))
+		for _, key := range keys {
+			if strings.HasSuffix(key.Name(), ".crt") {
+				// #nosec G304 - filename variable is derived from contents of 'trustDir' which is a defined constant
+				file, err := os.ReadFile(filepath.Join(trustDir, trustSet.Name(), key.Name()))
+				if err != nil {
+					return nil, fmt.Errorf("Failed to read file %s: %v", filepath.Join(trustDir, trustSet.Name(), key.Name()), err)
+				}
+				if pool.AppendCertsFromPEM(file) {
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Failed to find trust certificates for verifying metrics clients in %s", trustDir)
+	}
+	return pool, nil
+}
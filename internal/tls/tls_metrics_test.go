@@ -0,0 +1,77 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"testing"
+)
+
+func TestSetMetricsTLSConfig(t *testing.T) {
+	t.Cleanup(func() {
+		metricsTLS.config = nil
+	})
+	first := newTestCertificate(t, "first", nil, false, nil)
+	second := newTestCertificate(t, "second", nil, false, nil)
+	newConfig := func(c testCertificate) *cryptotls.Config {
+		return &cryptotls.Config{
+			Certificates: []cryptotls.Certificate{{Certificate: [][]byte{c.certificate.Raw}, PrivateKey: c.key, Leaf: c.certificate}},
+			MinVersion:   cryptotls.VersionTLS12,
+		}
+	}
+
+	config := setMetricsTLSConfig(newConfig(first), false)
+	if config.GetCertificate == nil || config.GetConfigForClient == nil {
+		t.Fatal("Expected the certificate and configuration to be supplied for each connection")
+	}
+	certificate, err := config.GetCertificate(&cryptotls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certificate.Leaf.Subject.CommonName != "first" {
+		t.Errorf("Expected certificate for first; got %v", certificate.Leaf.Subject)
+	}
+
+	// Connections made after the keys are reloaded use the new configuration
+	metricsTLS.config = newConfig(second)
+	certificate, err = config.GetCertificate(&cryptotls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certificate.Leaf.Subject.CommonName != "second" {
+		t.Errorf("Expected certificate for second after reloading; got %v", certificate.Leaf.Subject)
+	}
+	clientConfig, err := config.GetConfigForClient(&cryptotls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(clientConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Subject.CommonName != "second" {
+		t.Errorf("Expected connection configuration for second after reloading; got %v", leaf.Subject)
+	}
+}
+
+func TestReloadMetricsTLS_NotConfigured(t *testing.T) {
+	metricsTLS.config = nil
+	err := ReloadMetricsTLS("default")
+	if err != nil {
+		t.Errorf("Expected no error reloading when the metrics server doesn't use TLS; got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// keystoreDirReload is the location where the default keystores are rebuilt, before they replace the existing keystores
+const keystoreDirReload = "/run/runmqserver/tls-reload/"
+
+// DefaultTLSChecksum returns a checksum of the keys and trust certificates used for the default keystores and
+// the metrics server.  The checksum changes whenever a key or certificate is added, removed or updated.
+func DefaultTLSChecksum() (string, error) {
+	hash := sha256.New()
+	for _, dir := range []string{keyDirDefault, trustDirDefault, metricsKeyDir, metricsTrustDir} {
+		files, err := keyAndCertFiles(dir)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			// #nosec G304 - filename variable is derived from contents of a directory which is a defined constant
+			buf, err := os.ReadFile(file)
+			if err != nil {
+				return "", fmt.Errorf("Failed to read file %s: %v", file, err)
+			}
+			fileSum := sha256.Sum256(buf)
+			fmt.Fprintf(hash, "%s %x\n", file, fileSum)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ReloadDefaultTLSKeystores rebuilds the CMS Keystore & PKCS#12 Truststore from the current keys and trust
+// certificates, using the password of the existing keystores.  The keystores are built in a separate directory,
+// and each file is then renamed over the existing file, so no file is ever partly written.  The files are
+// replaced one at a time, so there is a short window in which a reader could see a mixture of old and new files,
+// for example a new key.kdb with the old key.rdb.  The stash files are replaced last.  The queue manager only
+// reads the new CMS Keystore when REFRESH SECURITY TYPE(SSL) is run after all of the files have been replaced,
+// and the web server keystores are each a single file.  The existing keystores are unchanged if the keys or
+// certificates are not valid.
+func ReloadDefaultTLSKeystores(password string, log *logger.Logger) (string, KeyStoreData, KeyStoreData, error) {
+	err := os.RemoveAll(keystoreDirReload)
+	if err != nil {
+		return "", KeyStoreData{}, KeyStoreData{}, fmt.Errorf("Failed to remove directory %s: %v", keystoreDirReload, err)
+	}
+	// #nosec G104 - the directory is created again next time, if it can't be removed
+	defer os.RemoveAll(keystoreDirReload)
+
+	keyLabel, cmsKeystore, p12Truststore, err := configureTLSKeystores(keystoreDirReload, keyDirDefault, trustDirDefault, password, true, false, log)
+	if err != nil {
+		return "", cmsKeystore, p12Truststore, err
+	}
+
+	files, err := os.ReadDir(keystoreDirReload)
+	if err != nil {
+		return "", cmsKeystore, p12Truststore, fmt.Errorf("Failed to read directory %s: %v", keystoreDirReload, err)
+	}
+	// Replace the stash files last, so that a new password is never used with an old keystore
+	sort.SliceStable(files, func(i, j int) bool {
+		return !strings.HasSuffix(files[i].Name(), ".sth") && strings.HasSuffix(files[j].Name(), ".sth")
+	})
+	for _, file := range files {
+		from := filepath.Join(keystoreDirReload, file.Name())
+		to := filepath.Join(keystoreDirDefault, file.Name())
+		err = os.Rename(from, to)
+		if err != nil {
+			return "", cmsKeystore, p12Truststore, fmt.Errorf("Failed to replace keystore file %s: %v", to, err)
+		}
+	}
+	if cmsKeystore.Keystore != nil {
+		cmsKeystore.Keystore.Filename = filepath.Join(keystoreDirDefault, cmsKeystoreName)
+	}
+	if p12Truststore.Keystore != nil {
+		p12Truststore.Keystore.Filename = filepath.Join(keystoreDirDefault, p12TruststoreName)
+	}
+	return keyLabel, cmsKeystore, p12Truststore, nil
+}
+
+// DefaultCertificateFingerprints returns a description of each certificate used for the default keystores,
+// including its SHA-256 fingerprint
+func DefaultCertificateFingerprints() ([]string, error) {
+	fingerprints := []string{}
+	for _, dir := range []string{keyDirDefault, trustDirDefault} {
+		files, err := keyAndCertFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".crt") {
+				continue
+			}
+			// #nosec G304 - filename variable is derived from contents of a directory which is a defined constant
+			buf, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read file %s: %v", file, err)
+			}
+			for {
+				var block *pem.Block
+				block, buf = pem.Decode(buf)
+				if block == nil {
+					break
+				}
+				certificate, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return nil, fmt.Errorf("Failed to parse certificate in %s: %v", file, err)
+				}
+				fingerprints = append(fingerprints, fmt.Sprintf("%s (%s) SHA-256 %s", file, certificate.Subject, formatFingerprint(certificate)))
+			}
+		}
+	}
+	return fingerprints, nil
+}
+
+// formatFingerprint returns the SHA-256 fingerprint of a certificate, as pairs of hex digits separated by colons
+func formatFingerprint(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.Raw)
+	pairs := make([]string, len(sum))
+	for i, b := range sum {
+		pairs[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(pairs, ":")
+}
+
+// keyAndCertFiles returns the paths of the keys (*.key, *.p12 or *.pfx), key passwords, channel lists and
+// certificates (*.crt) in each set of keys in a directory, in order.  Entries starting with ".." are skipped,
+// because they are created by Kubernetes when updating a mounted secret, and contain the same files.
+func keyAndCertFiles(dir string) ([]string, error) {
+	files := []string{}
+	sets, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return files, nil
+		}
+		return nil, fmt.Errorf("Failed to read directory %s: %v", dir, err)
+	}
+	for _, set := range sets {
+		if strings.HasPrefix(set.Name(), "..") {
+			continue
+		}
+		keys, _ := os.ReadDir(filepath.Join(dir, set.Name()))
+		for _, key := range keys {
+			if strings.HasPrefix(key.Name(), "..") {
+				continue
+			}
+			if isKeyFile(key.Name()) || key.Name() == keyPasswordFileName || key.Name() == channelsFileName || strings.HasSuffix(key.Name(), ".crt") {
+				files = append(files, filepath.Join(dir, set.Name(), key.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,311 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/pkg/logger"
+)
+
+// Actions taken when a set of keys fails validation, set using MQ_TLS_VALIDATION_MODE
+const (
+	validationModeFail = "fail"
+	validationModeWarn = "warn"
+)
+
+// maxChainLength is the maximum number of certificates followed when building a certificate chain
+const maxChainLength = 10
+
+// certificateFile is a certificate, and the file it was read from
+type certificateFile struct {
+	certificate *x509.Certificate
+	file        string
+}
+
+// getValidationMode returns the action to take when a set of keys fails validation
+func getValidationMode() (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MQ_TLS_VALIDATION_MODE")))
+	switch mode {
+	case "":
+		return validationModeWarn, nil
+	case validationModeFail, validationModeWarn:
+		return mode, nil
+	}
+	return "", fmt.Errorf("Invalid value for MQ_TLS_VALIDATION_MODE: %v.  The value must be '%s' or '%s'", mode, validationModeFail, validationModeWarn)
+}
+
+// validationEnabled returns false if certificate validation has been turned off by setting
+// MQ_ENABLE_CERT_VALIDATION to false
+func validationEnabled() bool {
+	enableValidation, enableValidationSet := os.LookupEnv("MQ_ENABLE_CERT_VALIDATION")
+	return !enableValidationSet || !strings.EqualFold(strings.TrimSpace(enableValidation), "false")
+}
+
+// validateKeySet checks a set of keys containing a private key (*.key) and certificates (*.crt), before it is
+// added to a keystore
+func validateKeySet(keyDir string, keySetName string, keyPrefix string, privateKey interface{}, trustDir string, log *logger.Logger) error {
+	keySetDir := filepath.Join(keyDir, keySetName)
+
+	public, caCertificates, err := readKeySetCertificates(keySetDir, keyPrefix)
+	if err != nil {
+		return err
+	}
+	if public.certificate == nil {
+		return fmt.Errorf("Failed to find public certificate %s.crt for private key in %s", keyPrefix, keySetDir)
+	}
+	return validateKeys(keySetName, filepath.Join(keySetDir, keyPrefix+".key"), privateKey, public, caCertificates, trustDir, log)
+}
+
+// validateKeys checks the keys from a set of keys before they are added to a keystore.  The private key must
+// match the public certificate, which is always checked, because the keystore cannot be created otherwise.
+// Unless validation has been turned off, the chain of the public certificate is then built from the CA
+// certificates in the set of keys and the trust certificates, and the signature and validity period of each
+// certificate in the chain is checked, along with the key usage of the public certificate.  Problems are
+// logged as warnings, or returned as an error if MQ_TLS_VALIDATION_MODE is set to 'fail'.  An incomplete chain
+// is always only a warning, because the issuing CA certificates are not required to be supplied.
+func validateKeys(keySetName string, keyFile string, privateKey interface{}, public certificateFile, caCertificates []certificateFile, trustDir string, log *logger.Logger) error {
+	err := checkPrivateKey(privateKey, keyFile, public)
+	if err != nil {
+		return err
+	}
+
+	if !validationEnabled() {
+		return nil
+	}
+	mode, err := getValidationMode()
+	if err != nil {
+		return err
+	}
+	trustCertificates, err := readTrustCertificates(trustDir)
+	if err != nil {
+		return err
+	}
+
+	problems, warnings := checkCertificateChain(public, append(caCertificates, trustCertificates...), time.Now())
+	for _, warning := range warnings {
+		log.Warnf("Certificate validation for key set %s: %v", keySetName, warning)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if mode == validationModeWarn {
+		for _, problem := range problems {
+			log.Warnf("Certificate validation for key set %s: %v", keySetName, problem)
+		}
+		return nil
+	}
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+	}
+	return fmt.Errorf("Certificate validation failed for key set %s: %s", keySetName, strings.Join(messages, "; "))
+}
+
+// readKeySetCertificates reads the public certificate, and the CA certificates, from a set of keys
+func readKeySetCertificates(keySetDir string, keyPrefix string) (certificateFile, []certificateFile, error) {
+	var public certificateFile
+	caCertificates := []certificateFile{}
+
+	keys, err := os.ReadDir(keySetDir)
+	if err != nil {
+		return public, nil, fmt.Errorf("Failed to read keys from %s: %v", keySetDir, err)
+	}
+	for _, key := range keys {
+		if !strings.HasSuffix(key.Name(), ".crt") {
+			continue
+		}
+		certificates, err := readCertificateFile(filepath.Join(keySetDir, key.Name()))
+		if err != nil {
+			return public, nil, err
+		}
+		if key.Name() == keyPrefix+".crt" {
+			// Only the first certificate in the public certificate file is added to the keystore
+			if len(certificates) > 0 {
+				public = certificates[0]
+			}
+		} else {
+			caCertificates = append(caCertificates, certificates...)
+		}
+	}
+	return public, caCertificates, nil
+}
+
+// readTrustCertificates reads the trust certificates (*.crt) in each set of certificates in a directory
+func readTrustCertificates(trustDir string) ([]certificateFile, error) {
+	trustCertificates := []certificateFile{}
+	files, err := keyAndCertFiles(trustDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".crt") {
+			continue
+		}
+		certificates, err := readCertificateFile(file)
+		if err != nil {
+			return nil, err
+		}
+		trustCertificates = append(trustCertificates, certificates...)
+	}
+	return trustCertificates, nil
+}
+
+// readCertificateFile reads each of the PEM encoded certificates in a file
+func readCertificateFile(file string) ([]certificateFile, error) {
+	// #nosec G304 - filename variable is derived from contents of a directory which is a defined constant
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read certificate %s: %v", file, err)
+	}
+	certificates := []certificateFile{}
+	for {
+		var block *pem.Block
+		block, buf = pem.Decode(buf)
+		if block == nil {
+			break
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse certificate %s: %v", file, err)
+		}
+		certificates = append(certificates, certificateFile{certificate, file})
+	}
+	return certificates, nil
+}
+
+// checkPrivateKey checks that a private key matches the public key in a certificate
+func checkPrivateKey(privateKey interface{}, keyFile string, public certificateFile) error {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("Unsupported type %T of private key %s", privateKey, keyFile)
+	}
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(public.certificate.PublicKey) {
+		return fmt.Errorf("Private key %s does not match the public key in certificate %s", keyFile, public.file)
+	}
+	return nil
+}
+
+// checkCertificateChain builds the chain of a public certificate from a set of candidate issuers, and returns
+// the problems found with the chain, and warnings about it
+func checkCertificateChain(public certificateFile, issuers []certificateFile, now time.Time) ([]error, []error) {
+	problems := []error{}
+	warnings := []error{}
+
+	problems = append(problems, checkValidity(public, now)...)
+	problems = append(problems, checkKeyUsage(public)...)
+	warnings = append(warnings, checkExtKeyUsage(public)...)
+
+	current := public
+	for i := 0; i < maxChainLength; i++ {
+		if isSelfSigned(current.certificate) {
+			return problems, warnings
+		}
+		candidates := []certificateFile{}
+		for _, issuer := range issuers {
+			if bytes.Equal(issuer.certificate.RawSubject, current.certificate.RawIssuer) && !bytes.Equal(issuer.certificate.Raw, current.certificate.Raw) {
+				candidates = append(candidates, issuer)
+			}
+		}
+		if len(candidates) == 0 {
+			warnings = append(warnings, fmt.Errorf("The chain of certificate %s is incomplete, because issuer '%s' of certificate %s was not found", public.file, current.certificate.Issuer, current.file))
+			return problems, warnings
+		}
+		var issuer *certificateFile
+		var signatureErr error
+		for j := range candidates {
+			signatureErr = current.certificate.CheckSignatureFrom(candidates[j].certificate)
+			if signatureErr == nil {
+				issuer = &candidates[j]
+				break
+			}
+		}
+		if issuer == nil {
+			problems = append(problems, fmt.Errorf("The signature of certificate %s could not be verified using issuer certificate %s: %v", current.file, candidates[0].file, signatureErr))
+			return problems, warnings
+		}
+		problems = append(problems, checkValidity(*issuer, now)...)
+		current = *issuer
+	}
+	warnings = append(warnings, fmt.Errorf("The chain of certificate %s is longer than %d certificates, so was not checked completely", public.file, maxChainLength))
+	return problems, warnings
+}
+
+// isSelfSigned returns true if a certificate is signed by its own key
+func isSelfSigned(certificate *x509.Certificate) bool {
+	if !bytes.Equal(certificate.RawSubject, certificate.RawIssuer) {
+		return false
+	}
+	return certificate.CheckSignature(certificate.SignatureAlgorithm, certificate.RawTBSCertificate, certificate.Signature) == nil
+}
+
+// checkValidity checks that the current time is within the validity period of a certificate
+func checkValidity(c certificateFile, now time.Time) []error {
+	if now.Before(c.certificate.NotBefore) {
+		return []error{fmt.Errorf("Certificate %s (%s) is not valid until %s", c.file, c.certificate.Subject, c.certificate.NotBefore.UTC().Format(time.RFC3339))}
+	}
+	if now.After(c.certificate.NotAfter) {
+		return []error{fmt.Errorf("Certificate %s (%s) expired on %s", c.file, c.certificate.Subject, c.certificate.NotAfter.UTC().Format(time.RFC3339))}
+	}
+	return nil
+}
+
+// checkKeyUsage checks that the key usage of a public certificate, if set, allows it to be used for TLS
+func checkKeyUsage(c certificateFile) []error {
+	usage := c.certificate.KeyUsage
+	if usage != 0 && usage&(x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment|x509.KeyUsageKeyAgreement) == 0 {
+		return []error{fmt.Errorf("The key usage of certificate %s does not include digital signature, key encipherment or key agreement, so it cannot be used for TLS", c.file)}
+	}
+	return nil
+}
+
+// checkExtKeyUsage checks the extended key usage of a public certificate, if it is set.  Warnings are returned if
+// it can't be used for server authentication, or for client authentication, because the queue manager is the
+// server for some channels and the client for others.
+func checkExtKeyUsage(c certificateFile) []error {
+	if len(c.certificate.ExtKeyUsage) == 0 && len(c.certificate.UnknownExtKeyUsage) == 0 {
+		return nil
+	}
+	serverAuth := false
+	clientAuth := false
+	for _, usage := range c.certificate.ExtKeyUsage {
+		switch usage {
+		case x509.ExtKeyUsageAny:
+			return nil
+		case x509.ExtKeyUsageServerAuth:
+			serverAuth = true
+		case x509.ExtKeyUsageClientAuth:
+			clientAuth = true
+		}
+	}
+	warnings := []error{}
+	if !serverAuth {
+		warnings = append(warnings, fmt.Errorf("The extended key usage of certificate %s does not include serverAuth, so channels started by other queue managers and clients might be rejected", c.file))
+	}
+	if !clientAuth {
+		warnings = append(warnings, fmt.Errorf("The extended key usage of certificate %s does not include clientAuth, so channels started by the queue manager might be rejected", c.file))
+	}
+	return warnings
+}
@@ -0,0 +1,298 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testNow is the time used when checking the validity of test certificates
+var testNow = time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// testCertificate is a certificate generated for a test, and its private key
+type testCertificate struct {
+	certificateFile
+	key crypto.Signer
+}
+
+// newTestKey generates a private key for a test certificate
+func newTestKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newTestCertificate generates a certificate which is valid at testNow, signed by the issuer, or self-signed if
+// the issuer is nil.  The template can be changed before the certificate is created.
+func newTestCertificate(t *testing.T, name string, issuer *testCertificate, isCA bool, modify func(*x509.Certificate)) testCertificate {
+	t.Helper()
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             testNow.Add(-24 * time.Hour),
+		NotAfter:              testNow.Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	if modify != nil {
+		modify(template)
+	}
+	parent := template
+	signer := key
+	if issuer != nil {
+		parent = issuer.certificate
+		signer = issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCertificate{certificateFile{certificate, name + ".crt"}, key}
+}
+
+func TestCheckValidity(t *testing.T) {
+	var validityTests = []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		problems  int
+	}{
+		{"Valid", testNow.Add(-time.Hour), testNow.Add(time.Hour), 0},
+		{"Expired", testNow.Add(-48 * time.Hour), testNow.Add(-time.Hour), 1},
+		{"NotYetValid", testNow.Add(time.Hour), testNow.Add(48 * time.Hour), 1},
+	}
+	for _, test := range validityTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestCertificate(t, "server", nil, false, func(template *x509.Certificate) {
+				template.NotBefore = test.notBefore
+				template.NotAfter = test.notAfter
+			})
+			problems := checkValidity(c.certificateFile, testNow)
+			if len(problems) != test.problems {
+				t.Errorf("Expected %d problems; got %v", test.problems, problems)
+			}
+		})
+	}
+}
+
+func TestCheckKeyUsage(t *testing.T) {
+	var keyUsageTests = []struct {
+		name     string
+		usage    x509.KeyUsage
+		problems int
+	}{
+		{"NotSet", 0, 0},
+		{"DigitalSignature", x509.KeyUsageDigitalSignature, 0},
+		{"KeyEncipherment", x509.KeyUsageKeyEncipherment, 0},
+		{"KeyAgreement", x509.KeyUsageKeyAgreement, 0},
+		{"CertSignOnly", x509.KeyUsageCertSign, 1},
+		{"ContentCommitmentOnly", x509.KeyUsageContentCommitment, 1},
+	}
+	for _, test := range keyUsageTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestCertificate(t, "server", nil, false, func(template *x509.Certificate) {
+				template.KeyUsage = test.usage
+			})
+			problems := checkKeyUsage(c.certificateFile)
+			if len(problems) != test.problems {
+				t.Errorf("Expected %d problems; got %v", test.problems, problems)
+			}
+		})
+	}
+}
+
+func TestCheckExtKeyUsage(t *testing.T) {
+	var extKeyUsageTests = []struct {
+		name     string
+		usage    []x509.ExtKeyUsage
+		warnings int
+	}{
+		{"NotSet", nil, 0},
+		{"ServerAndClient", []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, 0},
+		{"Any", []x509.ExtKeyUsage{x509.ExtKeyUsageAny}, 0},
+		{"ServerOnly", []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, 1},
+		{"ClientOnly", []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, 1},
+		{"CodeSigning", []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}, 2},
+	}
+	for _, test := range extKeyUsageTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestCertificate(t, "server", nil, false, func(template *x509.Certificate) {
+				template.ExtKeyUsage = test.usage
+			})
+			warnings := checkExtKeyUsage(c.certificateFile)
+			if len(warnings) != test.warnings {
+				t.Errorf("Expected %d warnings; got %v", test.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestCheckPrivateKey(t *testing.T) {
+	c := newTestCertificate(t, "server", nil, false, nil)
+	var privateKeyTests = []struct {
+		name       string
+		privateKey interface{}
+		valid      bool
+	}{
+		{"Matching", c.key, true},
+		{"Mismatched", newTestKey(t), false},
+		{"Unsupported", "key", false},
+	}
+	for _, test := range privateKeyTests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkPrivateKey(test.privateKey, "server.key", c.certificateFile)
+			if test.valid && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestIsSelfSigned(t *testing.T) {
+	root := newTestCertificate(t, "root", nil, true, nil)
+	leaf := newTestCertificate(t, "server", &root, false, nil)
+	// A certificate with its own name as the issuer, but signed by a different key
+	other := newTestCertificate(t, "other", nil, true, nil)
+	impostor := newTestCertificate(t, "other", &other, false, nil)
+
+	var selfSignedTests = []struct {
+		name        string
+		certificate testCertificate
+		expected    bool
+	}{
+		{"Root", root, true},
+		{"Leaf", leaf, false},
+		{"SameNameDifferentKey", impostor, false},
+	}
+	for _, test := range selfSignedTests {
+		t.Run(test.name, func(t *testing.T) {
+			if isSelfSigned(test.certificate.certificate) != test.expected {
+				t.Errorf("Expected isSelfSigned=%v", test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckCertificateChain(t *testing.T) {
+	root := newTestCertificate(t, "root", nil, true, nil)
+	intermediate := newTestCertificate(t, "intermediate", &root, true, nil)
+	leaf := newTestCertificate(t, "server", &intermediate, false, nil)
+	// A CA certificate with the same name as the intermediate, but a different key
+	wrongIntermediate := newTestCertificate(t, "intermediate", &root, true, nil)
+	expiredIntermediate := newTestCertificate(t, "expired", &root, true, func(template *x509.Certificate) {
+		template.NotAfter = testNow.Add(-time.Hour)
+	})
+	leafOfExpired := newTestCertificate(t, "server", &expiredIntermediate, false, nil)
+	expiredLeaf := newTestCertificate(t, "server", &intermediate, false, func(template *x509.Certificate) {
+		template.NotAfter = testNow.Add(-time.Hour)
+	})
+	notYetValidLeaf := newTestCertificate(t, "server", &intermediate, false, func(template *x509.Certificate) {
+		template.NotBefore = testNow.Add(time.Hour)
+	})
+	badKeyUsageLeaf := newTestCertificate(t, "server", &intermediate, false, func(template *x509.Certificate) {
+		template.KeyUsage = x509.KeyUsageCertSign
+	})
+	clientOnlyLeaf := newTestCertificate(t, "server", &intermediate, false, func(template *x509.Certificate) {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	selfSigned := newTestCertificate(t, "server", nil, false, nil)
+
+	var chainTests = []struct {
+		name     string
+		public   testCertificate
+		issuers  []testCertificate
+		problems int
+		warnings int
+	}{
+		{"SelfSigned", selfSigned, nil, 0, 0},
+		{"Complete", leaf, []testCertificate{intermediate, root}, 0, 0},
+		{"CompleteOutOfOrder", leaf, []testCertificate{root, intermediate}, 0, 0},
+		{"Incomplete", leaf, []testCertificate{intermediate}, 0, 1},
+		{"NoIssuers", leaf, nil, 0, 1},
+		{"WrongIssuerSignature", leaf, []testCertificate{wrongIntermediate, root}, 1, 0},
+		{"WrongAndRightIssuer", leaf, []testCertificate{wrongIntermediate, intermediate, root}, 0, 0},
+		{"ExpiredIssuer", leafOfExpired, []testCertificate{expiredIntermediate, root}, 1, 0},
+		{"Expired", expiredLeaf, []testCertificate{intermediate, root}, 1, 0},
+		{"NotYetValid", notYetValidLeaf, []testCertificate{intermediate, root}, 1, 0},
+		{"BadKeyUsage", badKeyUsageLeaf, []testCertificate{intermediate, root}, 1, 0},
+		{"BadExtKeyUsage", clientOnlyLeaf, []testCertificate{intermediate, root}, 0, 1},
+	}
+	for _, test := range chainTests {
+		t.Run(test.name, func(t *testing.T) {
+			issuers := []certificateFile{}
+			for _, issuer := range test.issuers {
+				issuers = append(issuers, issuer.certificateFile)
+			}
+			problems, warnings := checkCertificateChain(test.public.certificateFile, issuers, testNow)
+			if len(problems) != test.problems {
+				t.Errorf("Expected %d problems; got %v", test.problems, problems)
+			}
+			if len(warnings) != test.warnings {
+				t.Errorf("Expected %d warnings; got %v", test.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestReadKeySetCertificates(t *testing.T) {
+	root := newTestCertificate(t, "root", nil, true, nil)
+	leaf := newTestCertificate(t, "server", &root, false, nil)
+	dir := t.TempDir()
+	// The CA certificate file name starts with the name of the public certificate, but is not the public certificate
+	for file, c := range map[string]testCertificate{"tls.crt": leaf, "tls-ca.crt": root} {
+		buf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.certificate.Raw})
+		err := os.WriteFile(filepath.Join(dir, file), buf, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	public, caCertificates, err := readKeySetCertificates(dir, "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.certificate == nil || !public.certificate.Equal(leaf.certificate) {
+		t.Errorf("Expected public certificate %v; got %v", leaf.certificate.Subject, public.certificate)
+	}
+	if len(caCertificates) != 1 || !caCertificates[0].certificate.Equal(root.certificate) {
+		t.Errorf("Expected CA certificate %v; got %v", root.certificate.Subject, caCertificates)
+	}
+}
@@ -0,0 +1,289 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats for log entries
+const (
+	// FormatBasic is a simple human-readable format
+	FormatBasic = "basic"
+	// FormatJSON is a JSON object per line, using the MQ field names
+	FormatJSON = "json"
+	// FormatLogfmt is a line of key=value pairs, using the MQ field names
+	FormatLogfmt = "logfmt"
+	// FormatECS is a JSON object per line, using Elastic Common Schema field names
+	FormatECS = "ecs"
+	// FormatOTel is a JSON object per line, using the OpenTelemetry log data model
+	FormatOTel = "otel"
+)
+
+// ecsVersion is the version of the Elastic Common Schema used for ECS field names
+const ecsVersion = "8.0.0"
+
+// extensionPrefix is added to the names of fields which have no standard ECS or OpenTelemetry name
+const extensionPrefix = "ibmmq."
+
+// IsValidFormat returns true if the format is one of the supported formats
+func IsValidFormat(format string) bool {
+	switch format {
+	case FormatBasic, FormatJSON, FormatLogfmt, FormatECS, FormatOTel:
+		return true
+	}
+	return false
+}
+
+// ecsFields maps MQ and Liberty field names to ECS field names
+var ecsFields = map[string]string{
+	"ibm_datetime":    "@timestamp",
+	"loglevel":        "log.level",
+	"message":         "message",
+	"host":            "host.name",
+	"ibm_serverName":  "service.name",
+	"ibm_processId":   "process.pid",
+	"ibm_processName": "process.name",
+	"ibm_threadId":    "process.thread.id",
+	"ibm_userName":    "user.name",
+	"ibm_messageId":   "event.code",
+	"type":            "event.dataset",
+	"module":          "log.logger",
+	"ibm_className":   "log.origin.file.name",
+	"ibm_methodName":  "log.origin.function",
+}
+
+// otelResourceFields maps MQ and Liberty field names to OpenTelemetry resource attribute names
+var otelResourceFields = map[string]string{
+	"host":            "host.name",
+	"ibm_serverName":  "service.name",
+	"ibm_processId":   "process.pid",
+	"ibm_processName": "process.executable.name",
+	"ibm_userName":    "process.owner",
+}
+
+// otelAttributeFields maps MQ and Liberty field names to OpenTelemetry log attribute names
+var otelAttributeFields = map[string]string{
+	"ibm_threadId":   "thread.id",
+	"ibm_messageId":  "event.name",
+	"module":         "code.namespace",
+	"ibm_className":  "code.namespace",
+	"ibm_methodName": "code.function",
+}
+
+// FormatEntry formats a log entry for output, using the JSON, logfmt, ECS or OpenTelemetry formats.  The entry
+// uses the field names found in MQ and Liberty JSON logs.  No line terminator is added.
+func FormatEntry(entry map[string]interface{}, format string) (string, error) {
+	switch format {
+	case FormatJSON:
+		b, err := json.Marshal(entry)
+		return string(b), err
+	case FormatLogfmt:
+		return formatLogfmt(entry), nil
+	case FormatECS:
+		b, err := json.Marshal(toECS(entry))
+		return string(b), err
+	case FormatOTel:
+		b, err := json.Marshal(toOTel(entry))
+		return string(b), err
+	}
+	return "", fmt.Errorf("Unsupported log format: %v", format)
+}
+
+// extensionName returns the name of a field which has no standard name
+func extensionName(name string) string {
+	return extensionPrefix + strings.TrimPrefix(name, "ibm_")
+}
+
+// parseTimestamp parses the timestamp of an entry, which might use a time zone offset without a colon
+// (for example in Liberty logs)
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700"} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// numericValue returns a numeric string value, such as a process ID, as a number
+func numericValue(value interface{}) interface{} {
+	if s, ok := value.(string); ok {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err == nil {
+			return i
+		}
+	}
+	return value
+}
+
+func toECS(entry map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{"ecs.version": ecsVersion}
+	for k, v := range entry {
+		name, ok := ecsFields[k]
+		if !ok {
+			name = extensionName(k)
+		}
+		switch k {
+		case "ibm_datetime":
+			if t, ok := parseTimestamp(v); ok {
+				v = t.UTC().Format(timestampFormat)
+			}
+		case "loglevel":
+			if s, ok := v.(string); ok {
+				v = strings.ToLower(s)
+			}
+		case "ibm_processId", "ibm_threadId":
+			v = numericValue(v)
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// otelRecord is a log record in the OpenTelemetry log data model
+type otelRecord struct {
+	Timestamp      string                 `json:"Timestamp,omitempty"`
+	SeverityText   string                 `json:"SeverityText,omitempty"`
+	SeverityNumber int                    `json:"SeverityNumber,omitempty"`
+	Body           interface{}            `json:"Body,omitempty"`
+	Resource       map[string]interface{} `json:"Resource,omitempty"`
+	Attributes     map[string]interface{} `json:"Attributes,omitempty"`
+}
+
+// otelSeverityNumber returns the OpenTelemetry severity number for an MQ or Liberty log level
+func otelSeverityNumber(level string) int {
+	switch strings.ToUpper(level) {
+	case "FINEST", "FINER", "ENTRY", "EXIT":
+		return 1
+	case "FINE", "DEBUG":
+		return 5
+	case "INFO", "AUDIT", "EVENT":
+		return 9
+	case "WARNING", "WARN":
+		return 13
+	case "ERROR", "SEVERE":
+		return 17
+	case "FATAL":
+		return 21
+	}
+	return 0
+}
+
+func toOTel(entry map[string]interface{}) otelRecord {
+	record := otelRecord{
+		Resource:   map[string]interface{}{},
+		Attributes: map[string]interface{}{},
+	}
+	for k, v := range entry {
+		switch k {
+		case "ibm_datetime":
+			if t, ok := parseTimestamp(v); ok {
+				record.Timestamp = strconv.FormatInt(t.UnixNano(), 10)
+				continue
+			}
+		case "loglevel":
+			if s, ok := v.(string); ok {
+				record.SeverityText = s
+				record.SeverityNumber = otelSeverityNumber(s)
+				continue
+			}
+		case "message":
+			record.Body = v
+			continue
+		}
+		if name, ok := otelResourceFields[k]; ok {
+			if k == "ibm_processId" {
+				v = numericValue(v)
+			}
+			record.Resource[name] = v
+		} else if name, ok := otelAttributeFields[k]; ok {
+			if k == "ibm_threadId" {
+				v = numericValue(v)
+			}
+			record.Attributes[name] = v
+		} else {
+			record.Attributes[extensionName(k)] = v
+		}
+	}
+	return record
+}
+
+// logfmtFirstKeys are the keys which are written first in logfmt, in this order
+var logfmtFirstKeys = []string{"ibm_datetime", "loglevel", "message"}
+
+// logfmtKeys are the keys used in logfmt for the first keys
+var logfmtKeys = map[string]string{
+	"ibm_datetime": "time",
+	"loglevel":     "level",
+	"message":      "msg",
+}
+
+func formatLogfmt(entry map[string]interface{}) string {
+	keys := make([]string, 0, len(entry))
+	for k := range entry {
+		if _, ok := logfmtKeys[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(entry))
+	for _, k := range logfmtFirstKeys {
+		if v, ok := entry[k]; ok {
+			if s, ok := v.(string); ok && k == "loglevel" {
+				v = strings.ToLower(s)
+			}
+			pairs = append(pairs, logfmtKeys[k]+"="+logfmtValue(v))
+		}
+	}
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+logfmtValue(entry[k]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// logfmtValue formats a value for logfmt, quoting it if necessary
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case nil:
+		s = ""
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(b)
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\\\n\r\t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// mqLogEntry is an entry from an MQ JSON error log
+var mqLogEntry = map[string]interface{}{
+	"ibm_datetime":       "2023-06-24T10:00:00.123Z",
+	"loglevel":           "WARNING",
+	"message":            "AMQ9999W: Channel 'APP.SVRCONN' ended.",
+	"host":               "qm1-0",
+	"ibm_serverName":     "QM1",
+	"ibm_processId":      "123",
+	"ibm_threadId":       "4",
+	"ibm_messageId":      "AMQ9999W",
+	"ibm_commentInsert1": "APP.SVRCONN",
+	"ibm_arithInsert1":   float64(0),
+	"type":               "mq_log",
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	out, err := FormatEntry(mqLogEntry, FormatLogfmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `time=2023-06-24T10:00:00.123Z level=warning msg="AMQ9999W: Channel 'APP.SVRCONN' ended." host=qm1-0 ibm_arithInsert1=0 ibm_commentInsert1=APP.SVRCONN ibm_messageId=AMQ9999W ibm_processId=123 ibm_serverName=QM1 ibm_threadId=4 type=mq_log`
+	if out != expected {
+		t.Errorf("Expected %v; got %v", expected, out)
+	}
+}
+
+func TestFormatECS(t *testing.T) {
+	out, err := FormatEntry(mqLogEntry, FormatECS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	err = json.Unmarshal([]byte(out), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"@timestamp":           "2023-06-24T10:00:00.123Z",
+		"log.level":            "warning",
+		"host.name":            "qm1-0",
+		"service.name":         "QM1",
+		"process.pid":          float64(123),
+		"process.thread.id":    float64(4),
+		"event.code":           "AMQ9999W",
+		"event.dataset":        "mq_log",
+		"ibmmq.commentInsert1": "APP.SVRCONN",
+		"ecs.version":          ecsVersion,
+	}
+	for k, v := range expected {
+		if obj[k] != v {
+			t.Errorf("Expected %v=%v; got %v", k, v, obj[k])
+		}
+	}
+	if _, ok := obj["ibm_datetime"]; ok {
+		t.Errorf("Expected MQ field names to be replaced; got %v", out)
+	}
+}
+
+func TestFormatOTel(t *testing.T) {
+	liberty := map[string]interface{}{
+		"ibm_datetime":   "2023-06-24T10:00:00.123+0000",
+		"loglevel":       "SEVERE",
+		"message":        "CWWKE0701E: Error",
+		"ibm_serverName": "mqweb",
+		"module":         "com.ibm.ws.kernel",
+	}
+	out, err := FormatEntry(liberty, FormatOTel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record otelRecord
+	err = json.Unmarshal([]byte(out), &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Timestamp != "1687600800123000000" {
+		t.Errorf("Expected timestamp in nanoseconds; got %v", record.Timestamp)
+	}
+	if record.SeverityText != "SEVERE" || record.SeverityNumber != 17 {
+		t.Errorf("Expected severity SEVERE (17); got %v (%v)", record.SeverityText, record.SeverityNumber)
+	}
+	if record.Body != "CWWKE0701E: Error" {
+		t.Errorf("Expected body to contain message; got %v", record.Body)
+	}
+	if record.Resource["service.name"] != "mqweb" {
+		t.Errorf("Expected service.name resource attribute; got %v", record.Resource)
+	}
+	if record.Attributes["code.namespace"] != "com.ibm.ws.kernel" {
+		t.Errorf("Expected code.namespace attribute; got %v", record.Attributes)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the verbosity of a logger.  Messages are logged if their level is no higher than the level of
+// the logger.  Errors are always logged.
+type Level int
+
+// Log levels, from least to most verbose
+const (
+	LevelError Level = iota
+	LevelWarning
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+// componentField is the field which identifies the component a child logger is used for
+const componentField = "component"
+
+var levelNames = []string{"error", "warning", "info", "debug", "trace"}
+
+func (level Level) String() string {
+	if level < LevelError || level > LevelTrace {
+		return fmt.Sprintf("Level(%d)", int(level))
+	}
+	return levelNames[level]
+}
+
+// ParseLevel returns the level with the specified name
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warn" {
+		return LevelWarning, nil
+	}
+	for i, levelName := range levelNames {
+		if name == levelName {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("Invalid log level '%s'.  Allowed levels are 'error', 'warning', 'info', 'debug' and 'trace'", name)
+}
+
+// levels are the log levels shared by a logger and its child loggers
+type levels struct {
+	mutex        sync.RWMutex
+	defaultLevel Level
+	components   map[string]Level
+}
+
+// get returns the level for a component, or the default level if none is set
+func (lv *levels) get(component string) Level {
+	lv.mutex.RLock()
+	defer lv.mutex.RUnlock()
+	if level, ok := lv.components[component]; ok {
+		return level
+	}
+	return lv.defaultLevel
+}
+
+// Enabled returns true if messages at the specified level are logged by this logger.  The level of a child
+// logger created with a "component" field is the level set for that component, if any.
+func (l *Logger) Enabled(level Level) bool {
+	if level == LevelError {
+		return true
+	}
+	component, _ := l.fields[componentField].(string)
+	return level <= l.out.levels.get(component)
+}
+
+// SetLevels sets the log levels of this logger and its child loggers, from a comma-separated list of levels.
+// A level on its own is the default level, and a level can be set for a component using component=level.
+// For example, "info,metrics=debug,tls=trace".  Components which are not listed use the default level.
+func (l *Logger) SetLevels(spec string) error {
+	defaultLevel := LevelInfo
+	components := make(map[string]Level)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, name, found := strings.Cut(entry, "=")
+		if !found {
+			name = component
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		if found {
+			components[strings.TrimSpace(component)] = level
+		} else {
+			defaultLevel = level
+		}
+	}
+	l.out.levels.mutex.Lock()
+	defer l.out.levels.mutex.Unlock()
+	l.out.levels.defaultLevel = defaultLevel
+	l.out.levels.components = components
+	return nil
+}
+
+// Levels returns the current log levels, in the format used by SetLevels
+func (l *Logger) Levels() string {
+	l.out.levels.mutex.RLock()
+	defer l.out.levels.mutex.RUnlock()
+	entries := []string{l.out.levels.defaultLevel.String()}
+	components := make([]string, 0, len(l.out.levels.components))
+	for component := range l.out.levels.components {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		entries = append(entries, component+"="+l.out.levels.components[component].String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// LevelWatcher sets the log levels of a logger from a file, which can be changed while the process is
+// running.  The default levels are used when the file does not exist.
+type LevelWatcher struct {
+	log      *Logger
+	path     string
+	defaults string
+	mutex    sync.Mutex
+	modTime  time.Time
+	size     int64
+	present  bool
+}
+
+// NewLevelWatcher returns a watcher for a file containing log levels, in the format used by SetLevels
+func NewLevelWatcher(log *Logger, path string, defaults string) *LevelWatcher {
+	return &LevelWatcher{log: log, path: path, defaults: defaults}
+}
+
+// Run checks the file for changes at the specified interval, until the stop channel is closed
+func (w *LevelWatcher) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check(false)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Restore sets the log levels from the file if it exists, or otherwise to the default levels, even if the
+// levels have been changed in another way since the file was last read
+func (w *LevelWatcher) Restore() {
+	w.check(true)
+}
+
+// check applies the log levels from the file if it has changed, or the default levels if it has been removed
+func (w *LevelWatcher) check(force bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			w.log.Errorf("Unable to check log levels file %s: %v", w.path, err)
+			return
+		}
+		if w.present || force {
+			w.present = false
+			w.apply(w.defaults, "default")
+		}
+		return
+	}
+	if !force && w.present && fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return
+	}
+	w.present = true
+	w.modTime = fi.ModTime()
+	w.size = fi.Size()
+	// #nosec G304 - the file is in a directory owned by the container
+	buf, err := os.ReadFile(w.path)
+	if err != nil {
+		w.log.Errorf("Unable to read log levels file %s: %v", w.path, err)
+		return
+	}
+	w.apply(strings.TrimSpace(string(buf)), w.path)
+}
+
+// apply sets the log levels, and logs the change
+func (w *LevelWatcher) apply(spec string, source string) {
+	previous := w.log.Levels()
+	err := w.log.SetLevels(spec)
+	if err != nil {
+		w.log.Errorf("Invalid log levels in %s: %v", source, err)
+		return
+	}
+	if w.log.Levels() != previous {
+		w.log.Printf("Log levels changed to %s, from %s", w.log.Levels(), source)
+	}
+}
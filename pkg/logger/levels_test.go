@@ -0,0 +1,128 @@
+/*
+© Copyright IBM Corporation 2023
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"error":   LevelError,
+		"warn":    LevelWarning,
+		"WARNING": LevelWarning,
+		" info ":  LevelInfo,
+		"debug":   LevelDebug,
+		"trace":   LevelTrace,
+	}
+	for name, expected := range tests {
+		level, err := ParseLevel(name)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %v: %v", name, err)
+		} else if level != expected {
+			t.Errorf("Expected %v to be %v; got %v", name, expected, level)
+		}
+	}
+	_, err := ParseLevel("verbose")
+	if err == nil {
+		t.Error("Expected an error parsing an invalid level")
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, false, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.SetLevels("warn, metrics=debug,tls=trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Levels() != "warning,metrics=debug,tls=trace" {
+		t.Errorf("Unexpected levels: %v", l.Levels())
+	}
+	l.Print("not logged")
+	l.Warn("warning logged")
+	l.Error("error logged")
+	l.With("component", "metrics").Debug("metrics debug logged")
+	l.With("component", "metrics").Trace("metrics trace not logged")
+	l.With("component", "tls").Trace("tls trace logged")
+	out := buf.String()
+	for _, s := range []string{"warning logged", "error logged", "metrics debug logged", "TRACE: tls trace logged"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected log output to contain %v; got %v", s, out)
+		}
+	}
+	if strings.Contains(out, "not logged") {
+		t.Errorf("Expected messages above the log level not to be logged; got %v", out)
+	}
+	err = l.SetLevels("info,metrics=loud")
+	if err == nil {
+		t.Error("Expected an error setting an invalid level")
+	}
+	if l.Levels() != "warning,metrics=debug,tls=trace" {
+		t.Errorf("Expected levels to be unchanged after an error; got %v", l.Levels())
+	}
+}
+
+func TestLevelWatcher(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, false, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "log-levels")
+	w := NewLevelWatcher(l, path, "info")
+	w.Restore()
+	if l.Levels() != "info" {
+		t.Errorf("Expected default levels without a file; got %v", l.Levels())
+	}
+	err = os.WriteFile(path, []byte("info,tls=debug\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.check(false)
+	if l.Levels() != "info,tls=debug" {
+		t.Errorf("Expected levels from the file; got %v", l.Levels())
+	}
+	// Levels changed in another way are restored from the file
+	err = l.SetLevels("debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.check(false)
+	if l.Levels() != "debug" {
+		t.Errorf("Expected levels to be unchanged while the file is unchanged; got %v", l.Levels())
+	}
+	w.Restore()
+	if l.Levels() != "info,tls=debug" {
+		t.Errorf("Expected levels to be restored from the file; got %v", l.Levels())
+	}
+	err = os.Remove(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.check(false)
+	if l.Levels() != "info" {
+		t.Errorf("Expected default levels after the file is removed; got %v", l.Levels())
+	}
+}
@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2021
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -18,12 +18,13 @@ limitations under the License.
 package logger
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/user"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -33,22 +34,43 @@ const timestampFormat string = "2006-01-02T15:04:05.000Z07:00"
 const debugLevel string = "DEBUG"
 const infoLevel string = "INFO"
 const errorLevel string = "ERROR"
+const warningLevel string = "WARNING"
+const traceLevel string = "TRACE"
 
 // A Logger is used to log messages to stdout
 type Logger struct {
-	mutex       sync.Mutex
-	writer      io.Writer
-	debug       bool
-	json        bool
+	out         *output
+	logFormat   string
 	processName string
 	pid         string
 	serverName  string
 	host        string
 	userName    string
+	// fields are added to every entry logged
+	fields map[string]interface{}
 }
 
-// NewLogger creates a new logger
+// output is where log entries are written, which is shared by a logger and its child loggers
+type output struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	hooks  []func(entry map[string]interface{})
+	levels levels
+}
+
+// NewLogger creates a new logger, which uses either the JSON or basic format
 func NewLogger(writer io.Writer, debug bool, json bool, serverName string) (*Logger, error) {
+	if json {
+		return NewLoggerWithFormat(writer, debug, FormatJSON, serverName)
+	}
+	return NewLoggerWithFormat(writer, debug, FormatBasic, serverName)
+}
+
+// NewLoggerWithFormat creates a new logger, which uses the specified format
+func NewLoggerWithFormat(writer io.Writer, debug bool, format string, serverName string) (*Logger, error) {
+	if !IsValidFormat(format) {
+		return nil, fmt.Errorf("Unsupported log format: %v", format)
+	}
 	hostname, err := os.Hostname()
 	if err != nil {
 		return nil, err
@@ -61,11 +83,14 @@ func NewLogger(writer io.Writer, debug bool, json bool, serverName string) (*Log
 	if err == nil {
 		userName = user.Username
 	}
+	out := &output{writer: writer}
+	out.levels.defaultLevel = LevelInfo
+	if debug {
+		out.levels.defaultLevel = LevelDebug
+	}
 	return &Logger{
-		mutex:       sync.Mutex{},
-		writer:      writer,
-		debug:       debug,
-		json:        json,
+		out:         out,
+		logFormat:   format,
 		processName: os.Args[0],
 		pid:         strconv.Itoa(os.Getpid()),
 		serverName:  serverName,
@@ -74,50 +99,107 @@ func NewLogger(writer io.Writer, debug bool, json bool, serverName string) (*Log
 	}, nil
 }
 
-func (l *Logger) format(entry map[string]interface{}) (string, error) {
-	if l.json {
-		b, err := json.Marshal(entry)
-		if err != nil {
-			return "", err
+// AddHook adds a function which is called with each entry which is logged, for example to forward
+// log entries to another destination.  The entry must not be modified.
+func (l *Logger) AddHook(hook func(entry map[string]interface{})) {
+	l.out.mutex.Lock()
+	defer l.out.mutex.Unlock()
+	l.out.hooks = append(l.out.hooks, hook)
+}
+
+// WithFields returns a child logger, which adds the specified fields to every entry it logs, in addition to
+// any fields added by this logger.  In structured formats the fields are added to the entry, and in the basic
+// format they are added to the end of the message as key=value pairs.
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	child := *l
+	child.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	for k, v := range fields {
+		child.fields[k] = v
+	}
+	return &child
+}
+
+// With returns a child logger in the same way as WithFields, using alternate keys and values, for
+// example With("phase", "tls", "keyLabel", label)
+func (l *Logger) With(keyValues ...interface{}) *Logger {
+	fields := make(map[string]interface{}, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		var value interface{}
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
 		}
-		return string(b), err
+		fields[fmt.Sprint(keyValues[i])] = value
 	}
-	return fmt.Sprintf("%v %v\n", entry["ibm_datetime"], entry["message"]), nil
+	return l.WithFields(fields)
+}
+
+// structured returns true if the logger uses a structured format, with one entry per line
+func (l *Logger) structured() bool {
+	return l.logFormat != FormatBasic
+}
+
+func (l *Logger) format(entry map[string]interface{}) (string, error) {
+	if l.structured() {
+		return FormatEntry(entry, l.logFormat)
+	}
+	if len(l.fields) == 0 {
+		return fmt.Sprintf("%v %v\n", entry["ibm_datetime"], entry["message"]), nil
+	}
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+logfmtValue(entry[k]))
+	}
+	return fmt.Sprintf("%v %v %v\n", entry["ibm_datetime"], entry["message"], strings.Join(pairs, " ")), nil
 }
 
 // log logs a message at the specified level.  The message is enriched with
 // additional fields.
 func (l *Logger) log(level string, msg string) {
 	t := time.Now()
-	entry := map[string]interface{}{
-		"message":         fmt.Sprint(msg),
-		"ibm_datetime":    t.Format(timestampFormat),
-		"loglevel":        level,
-		"host":            l.host,
-		"ibm_serverName":  l.serverName,
-		"ibm_processName": l.processName,
-		"ibm_processId":   l.pid,
-		"ibm_userName":    l.userName,
-		"type":            "mq_containerlog",
+	entry := make(map[string]interface{}, len(l.fields)+9)
+	for k, v := range l.fields {
+		entry[k] = v
 	}
+	// The standard fields can't be replaced
+	entry["message"] = fmt.Sprint(msg)
+	entry["ibm_datetime"] = t.Format(timestampFormat)
+	entry["loglevel"] = level
+	entry["host"] = l.host
+	entry["ibm_serverName"] = l.serverName
+	entry["ibm_processName"] = l.processName
+	entry["ibm_processId"] = l.pid
+	entry["ibm_userName"] = l.userName
+	entry["type"] = "mq_containerlog"
 	s, err := l.format(entry)
-	l.mutex.Lock()
+	l.out.mutex.Lock()
 	if err != nil {
 		// TODO: Fix this
 		fmt.Println(err)
 	}
-	if l.json {
-		fmt.Fprintln(l.writer, s)
+	if l.structured() {
+		fmt.Fprintln(l.out.writer, s)
 	} else {
-		fmt.Fprint(l.writer, s)
+		fmt.Fprint(l.out.writer, s)
+	}
+	hooks := l.out.hooks
+	l.out.mutex.Unlock()
+	for _, hook := range hooks {
+		hook(entry)
 	}
-	l.mutex.Unlock()
 }
 
 // Debug logs a line as debug
 func (l *Logger) Debug(args ...interface{}) {
-	if l.debug {
-		if l.json {
+	if l.Enabled(LevelDebug) {
+		if l.structured() {
 			l.log(debugLevel, fmt.Sprint(args...))
 		} else {
 			l.log(debugLevel, "DEBUG: "+fmt.Sprint(args...))
@@ -127,8 +209,8 @@ func (l *Logger) Debug(args ...interface{}) {
 
 // Debugf logs a line as debug using format specifiers
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	if l.debug {
-		if l.json {
+	if l.Enabled(LevelDebug) {
+		if l.structured() {
 			l.log(debugLevel, fmt.Sprintf(format, args...))
 		} else {
 			l.log(debugLevel, fmt.Sprintf("DEBUG: "+format, args...))
@@ -136,9 +218,33 @@ func (l *Logger) Debugf(format string, args ...interface{}) {
 	}
 }
 
+// Trace logs a line as trace, which is more detailed than debug
+func (l *Logger) Trace(args ...interface{}) {
+	if l.Enabled(LevelTrace) {
+		if l.structured() {
+			l.log(traceLevel, fmt.Sprint(args...))
+		} else {
+			l.log(traceLevel, "TRACE: "+fmt.Sprint(args...))
+		}
+	}
+}
+
+// Tracef logs a line as trace using format specifiers
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	if l.Enabled(LevelTrace) {
+		if l.structured() {
+			l.log(traceLevel, fmt.Sprintf(format, args...))
+		} else {
+			l.log(traceLevel, fmt.Sprintf("TRACE: "+format, args...))
+		}
+	}
+}
+
 // Print logs a message as info
 func (l *Logger) Print(args ...interface{}) {
-	l.log(infoLevel, fmt.Sprint(args...))
+	if l.Enabled(LevelInfo) {
+		l.log(infoLevel, fmt.Sprint(args...))
+	}
 }
 
 // Println logs a message
@@ -148,12 +254,30 @@ func (l *Logger) Println(args ...interface{}) {
 
 // Printf logs a message as info using format specifiers
 func (l *Logger) Printf(format string, args ...interface{}) {
-	l.log(infoLevel, fmt.Sprintf(format, args...))
+	if l.Enabled(LevelInfo) {
+		l.log(infoLevel, fmt.Sprintf(format, args...))
+	}
 }
 
 // PrintString logs a string as info
 func (l *Logger) PrintString(msg string) {
-	l.log(infoLevel, msg)
+	if l.Enabled(LevelInfo) {
+		l.log(infoLevel, msg)
+	}
+}
+
+// Warn logs a message as a warning
+func (l *Logger) Warn(args ...interface{}) {
+	if l.Enabled(LevelWarning) {
+		l.log(warningLevel, fmt.Sprint(args...))
+	}
+}
+
+// Warnf logs a message as a warning using format specifiers
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if l.Enabled(LevelWarning) {
+		l.log(warningLevel, fmt.Sprintf(format, args...))
+	}
 }
 
 // Errorf logs a message as error
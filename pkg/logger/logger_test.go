@@ -1,5 +1,5 @@
 /*
-© Copyright IBM Corporation 2018, 2019
+© Copyright IBM Corporation 2018, 2023
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -53,3 +53,86 @@ func TestSimpleLogger(t *testing.T) {
 		t.Errorf("Expected log output to contain %v; got %v", s, buf.String())
 	}
 }
+
+func TestLoggerHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, false, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []map[string]interface{}{}
+	l.AddHook(func(entry map[string]interface{}) {
+		entries = append(entries, entry)
+	})
+	l.Debug("Not logged")
+	l.Errorf("Hello %v", "world")
+	if len(entries) != 1 {
+		t.Fatalf("Expected hook to be called once; got %v", len(entries))
+	}
+	if entries[0]["message"] != "Hello world" || entries[0]["loglevel"] != errorLevel {
+		t.Errorf("Expected hook to receive error message; got %v", entries[0])
+	}
+}
+
+func TestStructuredLoggers(t *testing.T) {
+	for _, format := range []string{FormatLogfmt, FormatECS, FormatOTel} {
+		t.Run(format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			l, err := NewLoggerWithFormat(buf, true, format, "QM1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Debug("Hello world")
+			out := buf.String()
+			if strings.Count(out, "\n") != 1 || strings.Contains(out, "DEBUG: ") {
+				t.Errorf("Expected a single line without a debug prefix; got %v", out)
+			}
+			if !strings.Contains(out, "Hello world") || !strings.Contains(out, "QM1") {
+				t.Errorf("Expected log output to contain message and server name; got %v", out)
+			}
+		})
+	}
+	_, err := NewLoggerWithFormat(new(bytes.Buffer), false, "xml", "QM1")
+	if err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestLoggerWithFieldsJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, true, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := l.With("phase", "tls", "errorCode", 2035).WithFields(map[string]interface{}{"keyLabel": "default", "message": "Replaced"})
+	child.Error("Failed")
+	var e map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e["phase"] != "tls" || e["errorCode"] != float64(2035) || e["keyLabel"] != "default" {
+		t.Errorf("Expected fields to be added to the entry; got %v", buf.String())
+	}
+	if e["message"] != "Failed" || e["type"] != "mq_containerlog" {
+		t.Errorf("Expected standard fields not to be replaced; got %v", buf.String())
+	}
+	// The parent logger is not changed
+	buf.Reset()
+	l.Print("Parent")
+	if strings.Contains(buf.String(), "phase") {
+		t.Errorf("Expected parent logger not to add fields; got %v", buf.String())
+	}
+}
+
+func TestLoggerWithFieldsBasic(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, false, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With("phase", "tls", "keyLabel", "my label").Print("Hello world")
+	if !strings.HasSuffix(buf.String(), "Hello world keyLabel=\"my label\" phase=tls\n") {
+		t.Errorf("Expected fields as key=value pairs after the message; got %v", buf.String())
+	}
+}